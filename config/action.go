@@ -6,23 +6,74 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/rubblelabs/ripple/crypto"
 	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/keystore"
 	"github.com/rubblelabs/ripple/websockets"
 )
 
 type Action struct {
-	Seed         data.Seed
+	Seed data.Seed
+	// Label of a seed in a keystore to use instead of Seed
+	Key          string
 	Fee          data.Value
 	KeyType      data.KeyType
 	AccountSets  []data.AccountSet
 	TrustSets    []data.TrustSet
 	OfferCreates []data.OfferCreate
 	Payments     []data.Payment
+	// Transactions of any type, processed in order after the typed lists
+	Transactions TransactionSlice
+}
+
+// TransactionSlice decodes each transaction according to its TransactionType
+type TransactionSlice []data.Transaction
+
+func (s *TransactionSlice) UnmarshalJSON(b []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	txs := make(TransactionSlice, len(raw))
+	for i := range raw {
+		tx, err := ParseTransaction(raw[i])
+		if err != nil {
+			return fmt.Errorf("transaction %d: %s", i, err)
+		}
+		txs[i] = tx
+	}
+	*s = txs
+	return nil
+}
+
+// ParseTransaction decodes a JSON transaction of any TransactionType
+func ParseTransaction(b []byte) (data.Transaction, error) {
+	var header struct {
+		TransactionType string
+	}
+	if err := json.Unmarshal(b, &header); err != nil {
+		return nil, err
+	}
+	if header.TransactionType == "" {
+		return nil, fmt.Errorf("missing TransactionType")
+	}
+	var txType data.TransactionType
+	if err := txType.UnmarshalText([]byte(header.TransactionType)); err != nil {
+		return nil, err
+	}
+	tx := data.GetTxFactoryByType(header.TransactionType)()
+	if err := json.Unmarshal(b, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
 }
 
 type actionFunc func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error
 
 func (a *Action) each(f actionFunc) error {
+	if err := checkKey(a.Key, a.Seed); err != nil {
+		return err
+	}
 	for i := range a.AccountSets {
 		if err := f(a.Seed, a.Fee, a.KeyType, &a.AccountSets[i], data.ACCOUNT_SET); err != nil {
 			return err
@@ -43,6 +94,11 @@ func (a *Action) each(f actionFunc) error {
 			return err
 		}
 	}
+	for _, tx := range a.Transactions {
+		if err := f(a.Seed, a.Fee, a.KeyType, tx, tx.GetTransactionType()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -56,6 +112,39 @@ func Parse(r io.Reader) (ActionSlice, error) {
 	return actions, nil
 }
 
+// UseKeystore sets the Seed and KeyType of each action which has a Key
+func (s ActionSlice) UseKeystore(ks *keystore.Keystore) error {
+	for i := range s {
+		if err := useKey(ks, s[i].Key, &s[i].Seed, &s[i].KeyType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func useKey(ks *keystore.Keystore, key string, seed *data.Seed, keyType *data.KeyType) error {
+	if key == "" {
+		return nil
+	}
+	if *seed != (data.Seed{}) {
+		return fmt.Errorf("both Seed and Key %s are set", key)
+	}
+	s, t, err := ks.Export(key)
+	if err != nil {
+		return err
+	}
+	*seed, *keyType = *s, t
+	return nil
+}
+
+// Accounts use the first key of an ECDSA family, ed25519 keys have no family
+func checkKey(key string, seed data.Seed) error {
+	if key != "" && seed == (data.Seed{}) {
+		return fmt.Errorf("Key %s has not been read from a keystore", key)
+	}
+	return nil
+}
+
 func (s ActionSlice) each(f actionFunc) error {
 	for i := range s {
 		if err := s[i].each(f); err != nil {
@@ -68,25 +157,89 @@ func (s ActionSlice) each(f actionFunc) error {
 func (s ActionSlice) Prepare() error {
 	var prepare = func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error {
 		var (
-			sequence uint32
 			key      = seed.Key(keyType)
+			sequence = crypto.AccountSequence(key)
 			base     = tx.GetBase()
 		)
 		base.TransactionType = txType
-		base.Fee = fee
-		base.Account = seed.AccountId(keyType, &sequence)
-		return data.Sign(tx, key, &sequence)
+		if !fee.IsZero() {
+			base.Fee = fee
+		}
+		base.Account = seed.AccountId(keyType, sequence)
+		return data.Sign(tx, key, sequence)
 	}
 	return s.each(prepare)
 }
 
+// PrepareOnline fills in the fields which Prepare expects the action file
+// to provide from the network and then signs the transactions. Transactions
+// without a Sequence are given consecutive sequences starting from their
+// account's current Sequence. Actions without a Fee use the open ledger
+// fee and transactions without a LastLedgerSequence expire ledgerOffset
+// ledgers after the current open ledger.
+func (s ActionSlice) PrepareOnline(client websockets.Client, ledgerOffset uint32) error {
+	var (
+		networkFee *data.Value
+		lastLedger *uint32
+		sequences  = make(map[data.Account]*websockets.SequenceAllocator)
+	)
+	var autofill = func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error {
+		var (
+			base    = tx.GetBase()
+			account = seed.AccountId(keyType, crypto.AccountSequence(seed.Key(keyType)))
+		)
+		if base.Sequence == 0 && data.TicketSequence(tx) == nil {
+			if sequences[account] == nil {
+				sequences[account] = websockets.NewSequenceAllocator(client, account)
+			}
+			sequence, err := sequences[account].Next()
+			if err != nil {
+				return err
+			}
+			base.Sequence = sequence
+		}
+		if fee.IsZero() && base.Fee.IsZero() {
+			if networkFee == nil {
+				result, err := client.Fee()
+				if err != nil {
+					return err
+				}
+				suggested := result.Suggested()
+				networkFee = &suggested
+			}
+			base.Fee = *networkFee
+		}
+		if base.LastLedgerSequence == nil {
+			if lastLedger == nil {
+				current, err := client.LedgerCurrent()
+				if err != nil {
+					return err
+				}
+				ledger := current.LedgerSequence + ledgerOffset
+				lastLedger = &ledger
+			}
+			last := *lastLedger
+			base.LastLedgerSequence = &last
+		}
+		return nil
+	}
+	if err := s.each(autofill); err != nil {
+		return err
+	}
+	return s.Prepare()
+}
+
 func (s ActionSlice) Submit(host string) error {
 	remote, err := websockets.NewRemote(host)
 	if err != nil {
 		return err
 	}
+	return s.SubmitTo(remote)
+}
+
+func (s ActionSlice) SubmitTo(client websockets.Client) error {
 	var submit = func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error {
-		result, err := remote.Submit(tx)
+		result, err := client.Submit(tx)
 		if err != nil {
 			return err
 		}
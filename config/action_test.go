@@ -1,8 +1,16 @@
 package config
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/keystore"
+	"github.com/rubblelabs/ripple/websockets"
 )
 
 func TestParse(t *testing.T) {
@@ -20,3 +28,143 @@ func TestParse(t *testing.T) {
 	}
 	// t.Log(actions)
 }
+
+func TestTransactions(t *testing.T) {
+	f, err := os.Open("testdata/transactions.json")
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer f.Close()
+	actions, err := Parse(f)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if count := actions.Count(); count != 5 {
+		t.Fatalf("count: %d", count)
+	}
+	if err := actions.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	var types []string
+	actions.each(func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error {
+		types = append(types, tx.GetTransactionType().String())
+		return nil
+	})
+	if got := strings.Join(types, ","); got != "Payment,TicketCreate,EscrowCreate,CheckCreate,Payment" {
+		t.Errorf("order: %s", got)
+	}
+	if ticket := actions[0].Transactions[2].(*data.CheckCreate).TicketSequence; ticket == nil || *ticket != 3 {
+		t.Errorf("ticket: %v", ticket)
+	}
+	for i, tx := range actions[0].Transactions {
+		if ok, err := data.CheckSignature(tx); !ok || err != nil {
+			t.Errorf("%d: bad signature: %v", i, err)
+		}
+	}
+}
+
+func TestUnknownTransactionType(t *testing.T) {
+	for _, tx := range []string{`{"Destination":"rb1fWuuAEtPUaeEWxocV3h4x5JwDTFZzH"}`, `{"TransactionType":"Teleport"}`} {
+		if _, err := Parse(strings.NewReader(`[{"transactions":[` + tx + `]}]`)); err == nil {
+			t.Errorf("%s: expected error", tx)
+		}
+	}
+}
+
+func TestKeystore(t *testing.T) {
+	seed, err := data.NewSeedFromAddress("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, err := keystore.New([]byte("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Add("treasury", *seed, data.Ed25519); err != nil {
+		t.Fatal(err)
+	}
+	actions, err := Parse(strings.NewReader(`[{"key":"treasury","fee":"12","payments":[{"sequence":1,"destination":"rb1fWuuAEtPUaeEWxocV3h4x5JwDTFZzH","amount":"1000000"}]}]`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := actions.Prepare(); err == nil || !strings.Contains(err.Error(), "has not been read from a keystore") {
+		t.Fatalf("prepare without keystore: %v", err)
+	}
+	if err := actions.UseKeystore(ks); err != nil {
+		t.Fatalf("keystore: %v", err)
+	}
+	if err := actions.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	payment := &actions[0].Payments[0]
+	if payment.Account != seed.AccountId(data.Ed25519, nil) {
+		t.Errorf("account: %s", payment.Account)
+	}
+	if ok, err := data.CheckSignature(payment); !ok || err != nil {
+		t.Errorf("bad signature: %v", err)
+	}
+	actions[0].Key = "cold"
+	if err := actions.UseKeystore(ks); err == nil {
+		t.Errorf("expected both Seed and Key to be refused")
+	}
+}
+
+func TestPrepareOnline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var request struct {
+			Method string
+		}
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result := map[string]interface{}{"status": "success"}
+		switch request.Method {
+		case "account_info":
+			result["account_data"] = map[string]interface{}{
+				"LedgerEntryType": "AccountRoot",
+				"Sequence":        7,
+			}
+		case "fee":
+			result["drops"] = map[string]interface{}{
+				"base_fee":        "10",
+				"open_ledger_fee": "12",
+			}
+		case "ledger_current":
+			result["ledger_current_index"] = 100
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+	}))
+	defer server.Close()
+
+	f, err := os.Open("testdata/online.json")
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer f.Close()
+	actions, err := Parse(f)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := actions.PrepareOnline(websockets.NewRPCClient(server.URL, nil), 5); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	for i, tx := range []data.Transaction{&actions[0].Payments[0], &actions[0].Payments[1], &actions[1].TrustSets[0]} {
+		base := tx.GetBase()
+		if base.Sequence != uint32(7+i) {
+			t.Errorf("%d: sequence %d", i, base.Sequence)
+		}
+		if base.LastLedgerSequence == nil || *base.LastLedgerSequence != 105 {
+			t.Errorf("%d: LastLedgerSequence %v", i, base.LastLedgerSequence)
+		}
+		if ok, err := data.CheckSignature(tx); !ok || err != nil {
+			t.Errorf("%d: bad signature: %v", i, err)
+		}
+	}
+	if fee := actions[0].Payments[0].Fee.String(); fee != "0.000012" {
+		t.Errorf("network fee %s", fee)
+	}
+	if fee := actions[1].TrustSets[0].Fee.String(); fee != "0.000015" {
+		t.Errorf("action fee %s", fee)
+	}
+}
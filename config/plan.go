@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// PlanEntry describes a prepared transaction as it would be submitted
+type PlanEntry struct {
+	Account         data.Account
+	TransactionType data.TransactionType
+	Sequence        uint32
+	TicketSequence  *uint32 `json:",omitempty"`
+	Fee             data.Value
+	Hash            data.Hash256
+	TxBlob          string
+	Transaction     data.Transaction `json:"-"`
+}
+
+func (e PlanEntry) String() string {
+	sequence := fmt.Sprintf("Sequence: %d", e.Sequence)
+	if e.TicketSequence != nil {
+		sequence = fmt.Sprintf("Ticket: %d", *e.TicketSequence)
+	}
+	return fmt.Sprintf("%s %-16s %s Fee: %s %s", e.Account, e.TransactionType, sequence, e.Fee, e.Hash)
+}
+
+// Plan preflights the prepared transactions and lists them in the order
+// they would be submitted. Nothing is sent to the network.
+func (s ActionSlice) Plan() ([]PlanEntry, error) {
+	var (
+		plan []PlanEntry
+		used = make(map[data.Account]map[uint32]bool)
+	)
+	err := s.each(func(seed data.Seed, fee data.Value, keyType data.KeyType, tx data.Transaction, txType data.TransactionType) error {
+		if err := preflight(tx); err != nil {
+			return fmt.Errorf("%s %d: %s\n%s", txType, len(plan), err, js(tx))
+		}
+		base := tx.GetBase()
+		ticket := data.TicketSequence(tx)
+		sequence := base.Sequence
+		if ticket != nil {
+			sequence = *ticket
+		}
+		if used[base.Account] == nil {
+			used[base.Account] = make(map[uint32]bool)
+		}
+		if used[base.Account][sequence] {
+			return fmt.Errorf("%s %d: %s reuses sequence or ticket %d", txType, len(plan), base.Account, sequence)
+		}
+		used[base.Account][sequence] = true
+		_, raw, err := data.Raw(tx)
+		if err != nil {
+			return err
+		}
+		plan = append(plan, PlanEntry{
+			Account:         base.Account,
+			TransactionType: txType,
+			Sequence:        base.Sequence,
+			TicketSequence:  ticket,
+			Fee:             base.Fee,
+			Hash:            base.Hash,
+			TxBlob:          fmt.Sprintf("%X", raw),
+			Transaction:     tx,
+		})
+		return nil
+	})
+	return plan, err
+}
+
+// WriteBlobs writes the tx_blob of each entry on its own line, which is
+// the format read by "explain - -simulate" and "submit -blobs"
+func WriteBlobs(w io.Writer, plan []PlanEntry) error {
+	for _, entry := range plan {
+		if _, err := fmt.Fprintln(w, entry.TxBlob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The checks rippled would make before a signed transaction is relayed
+// which need no ledger state
+func preflight(tx data.Transaction) error {
+	base := tx.GetBase()
+	switch {
+	case base.Account.IsZero():
+		return fmt.Errorf("missing Account")
+	case base.Fee.IsZero():
+		return fmt.Errorf("missing Fee")
+	case base.Fee.IsNegative() || !base.Fee.IsNative():
+		return fmt.Errorf("bad Fee: %s", base.Fee)
+	case base.Sequence == 0 && data.TicketSequence(tx) == nil:
+		return fmt.Errorf("missing Sequence")
+	case base.Sequence != 0 && data.TicketSequence(tx) != nil:
+		return fmt.Errorf("both Sequence and TicketSequence set")
+	case base.SigningPubKey == nil || base.TxnSignature == nil:
+		return fmt.Errorf("not signed")
+	}
+	ok, err := data.CheckSignature(tx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("bad signature")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+func readActions(t *testing.T, path string) ActionSlice {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer f.Close()
+	actions, err := Parse(f)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return actions
+}
+
+func TestPlan(t *testing.T) {
+	actions := readActions(t, "testdata/transactions.json")
+	if _, err := actions.Plan(); err == nil || !strings.Contains(err.Error(), "missing Account") {
+		t.Fatalf("unsigned: %v", err)
+	}
+	if err := actions.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	plan, err := actions.Plan()
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if len(plan) != 5 {
+		t.Fatalf("plan length: %d", len(plan))
+	}
+	if ticket := plan[3].TicketSequence; ticket == nil || *ticket != 3 || plan[3].Sequence != 0 {
+		t.Errorf("ticket: %v", plan[3])
+	}
+	var buf bytes.Buffer
+	if err := WriteBlobs(&buf, plan); err != nil {
+		t.Fatalf("write blobs: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(plan) {
+		t.Fatalf("blobs: %d", len(lines))
+	}
+	for i, line := range lines {
+		b, err := hex.DecodeString(line)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		tx, err := data.ReadTransaction(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		hash, _, err := data.Raw(tx)
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if hash != plan[i].Hash || hash.IsZero() {
+			t.Errorf("%d: hash %s != %s", i, hash, plan[i].Hash)
+		}
+	}
+}
+
+func TestPlanReusedSequence(t *testing.T) {
+	actions := readActions(t, "testdata/transactions.json")
+	actions[0].Transactions[3].GetBase().Sequence = 5
+	if err := actions.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+	if _, err := actions.Plan(); err == nil || !strings.Contains(err.Error(), "reuses sequence") {
+		t.Fatalf("expected reused sequence: %v", err)
+	}
+}
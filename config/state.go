@@ -0,0 +1,446 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/keystore"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// AccountState is the desired configuration of an account. Omitted fields
+// are not managed and are left as they are in the ledger.
+type AccountState struct {
+	Seed data.Seed
+	// Label of a seed in a keystore to use instead of Seed
+	Key     string
+	Fee     data.Value
+	KeyType data.KeyType
+	// AccountSet flags by name, ie. "RequireAuth": true
+	Flags map[string]bool
+	// An empty Domain removes it
+	Domain *string
+	// In billionths, ie. 1002000000 for a 0.2% fee. 0 removes it.
+	TransferRate *uint32
+	// Only the listed trust lines are managed. A zero limit removes a line.
+	TrustLines []TrustLineState
+	// A zero SignerQuorum removes the signer list
+	SignerList *SignerListState
+	// An empty RegularKey removes it
+	RegularKey *string
+}
+
+type TrustLineState struct {
+	Limit      data.Amount
+	NoRipple   *bool
+	Freeze     *bool
+	QualityIn  *uint32
+	QualityOut *uint32
+}
+
+type SignerListState struct {
+	SignerQuorum  uint32
+	SignerEntries []SignerState
+}
+
+type SignerState struct {
+	Account      data.Account
+	SignerWeight uint16
+}
+
+// Difference is a field whose ledger value differs from the desired state
+type Difference struct {
+	Field   string
+	Current string
+	Desired string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s -> %s", d.Field, d.Current, d.Desired)
+}
+
+// Reconciliation lists the differences between an account and its desired
+// state along with an Action holding the transactions which resolve them.
+type Reconciliation struct {
+	Account     data.Account
+	Differences []Difference
+	Action      Action
+}
+
+// The AccountSet flags which can be managed, with their SetFlag value and
+// AccountRoot flag
+var accountFlags = map[string]struct {
+	Set    data.TransactionFlag
+	Ledger data.LedgerEntryFlag
+}{
+	"RequireDest":   {data.TxSetRequireDest, data.LsRequireDestTag},
+	"RequireAuth":   {data.TxSetRequireAuth, data.LsRequireAuth},
+	"DisallowXRP":   {data.TxSetDisallowXRP, data.LsDisallowXRP},
+	"DisableMaster": {data.TxSetDisableMaster, data.LsDisableMaster},
+	"NoFreeze":      {data.TxNoFreeze, data.LsNoFreeze},
+	"GlobalFreeze":  {data.TxGlobalFreeze, data.LsGlobalFreeze},
+	"DefaultRipple": {data.TxDefaultRipple, data.LsDefaultRipple},
+}
+
+// A transfer rate of exactly 1 is the same as none
+const transferRateNone = 1000000000
+
+func ParseAccounts(r io.Reader) ([]AccountState, error) {
+	var states []AccountState
+	if err := json.NewDecoder(r).Decode(&states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// UseKeystore sets the Seed and KeyType if the state has a Key
+func (s *AccountState) UseKeystore(ks *keystore.Keystore) error {
+	return useKey(ks, s.Key, &s.Seed, &s.KeyType)
+}
+
+// Reconcile compares the desired state with the account in the current
+// ledger and works out the fewest transactions needed to reach it. The
+// transactions are ordered so that a new regular key or signer list exists
+// before the master key is disabled, and so that they are removed after it
+// is enabled. The returned Action still needs preparing and is signed with
+// the master seed, so an account whose master key is disabled can only be
+// checked.
+func (s *AccountState) Reconcile(client websockets.Client) (*Reconciliation, error) {
+	if err := checkKey(s.Key, s.Seed); err != nil {
+		return nil, err
+	}
+	r := &Reconciliation{
+		Account: s.Seed.AccountId(s.KeyType, crypto.AccountSequence(s.Seed.Key(s.KeyType))),
+		Action: Action{
+			Seed:    s.Seed,
+			Fee:     s.Fee,
+			KeyType: s.KeyType,
+		},
+	}
+	info, err := client.AccountInfo(r.Account)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		before, after []data.Transaction
+		accountSets   []data.Transaction
+		trustSets     []data.Transaction
+		disableMaster data.Transaction
+	)
+	if s.RegularKey != nil {
+		tx, err := r.regularKey(info.AccountData.RegularKey, *s.RegularKey)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tx == nil:
+		case tx.RegularKey != nil:
+			before = append(before, tx)
+		default:
+			after = append(after, tx)
+		}
+	}
+	if s.SignerList != nil {
+		tx, err := r.signerList(client, s.SignerList)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tx == nil:
+		case tx.SignerQuorum > 0:
+			before = append(before, tx)
+		default:
+			after = append(after, tx)
+		}
+	}
+	accountSets, disableMaster, err = r.accountSets(s, &info.AccountData)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.TrustLines) > 0 {
+		lines, err := client.AccountLines(r.Account, "current")
+		if err != nil {
+			return nil, err
+		}
+		trustSets = r.trustSets(s.TrustLines, lines.Lines)
+	}
+	for _, txs := range [][]data.Transaction{before, accountSets, trustSets, after} {
+		r.Action.Transactions = append(r.Action.Transactions, txs...)
+	}
+	if disableMaster != nil {
+		r.Action.Transactions = append(r.Action.Transactions, disableMaster)
+	}
+	// The transactions are signed with the master seed
+	flags := info.AccountData.Flags
+	if flags != nil && *flags&data.LsDisableMaster != 0 && len(r.Action.Transactions) > 0 {
+		return nil, fmt.Errorf("%s: the master key is disabled so its seed cannot sign the %d transactions needed", r.Account, len(r.Action.Transactions))
+	}
+	return r, nil
+}
+
+func (r *Reconciliation) differ(field string, current, desired interface{}) {
+	r.Differences = append(r.Differences, Difference{
+		Field:   field,
+		Current: fmt.Sprint(current),
+		Desired: fmt.Sprint(desired),
+	})
+}
+
+// Flags are set and cleared one of each per AccountSet, with Domain and
+// TransferRate carried by the first. Setting DisableMaster is returned
+// separately as nothing can be signed with the master key afterwards.
+func (r *Reconciliation) accountSets(s *AccountState, root *data.AccountRoot) ([]data.Transaction, data.Transaction, error) {
+	var current data.LedgerEntryFlag
+	if root.Flags != nil {
+		current = *root.Flags
+	}
+	names := make([]string, 0, len(s.Flags))
+	for name := range s.Flags {
+		if _, ok := accountFlags[name]; !ok {
+			return nil, nil, fmt.Errorf("%s: unknown flag: %s", r.Account, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var (
+		sets, clears  []uint32
+		disableMaster data.Transaction
+	)
+	for _, name := range names {
+		flag, desired := accountFlags[name], s.Flags[name]
+		isSet := current&flag.Ledger != 0
+		if isSet == desired {
+			continue
+		}
+		r.differ("Flags."+name, isSet, desired)
+		switch {
+		case name == "NoFreeze" && !desired:
+			return nil, nil, fmt.Errorf("%s: NoFreeze cannot be cleared", r.Account)
+		case name == "DisableMaster" && !desired:
+			return nil, nil, fmt.Errorf("%s: DisableMaster must be cleared with the regular key or signer list, not the master seed", r.Account)
+		case name == "DisableMaster" && desired:
+			tx := newAccountSet(r.Account)
+			setFlag := uint32(flag.Set)
+			tx.SetFlag = &setFlag
+			disableMaster = tx
+		case desired:
+			sets = append(sets, uint32(flag.Set))
+		default:
+			clears = append(clears, uint32(flag.Set))
+		}
+	}
+	first := newAccountSet(r.Account)
+	if s.Domain != nil {
+		var domain []byte
+		if root.Domain != nil {
+			domain = *root.Domain
+		}
+		if !bytes.Equal(domain, []byte(*s.Domain)) {
+			r.differ("Domain", quote(string(domain)), quote(*s.Domain))
+			value := data.VariableLength(*s.Domain)
+			first.Domain = &value
+		}
+	}
+	if s.TransferRate != nil {
+		var rate uint32
+		if root.TransferRate != nil && *root.TransferRate != transferRateNone {
+			rate = *root.TransferRate
+		}
+		desired := *s.TransferRate
+		if desired == transferRateNone {
+			desired = 0
+		}
+		if rate != desired {
+			r.differ("TransferRate", rate, desired)
+			first.TransferRate = &desired
+		}
+	}
+	var txs []data.Transaction
+	for i := 0; i < len(sets) || i < len(clears); i++ {
+		tx := newAccountSet(r.Account)
+		if i == 0 {
+			tx = first
+		}
+		if i < len(sets) {
+			tx.SetFlag = &sets[i]
+		}
+		if i < len(clears) {
+			tx.ClearFlag = &clears[i]
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 && (first.Domain != nil || first.TransferRate != nil) {
+		txs = append(txs, first)
+	}
+	return txs, disableMaster, nil
+}
+
+func newAccountSet(account data.Account) *data.AccountSet {
+	return &data.AccountSet{
+		TxBase: data.TxBase{
+			TransactionType: data.ACCOUNT_SET,
+			Account:         account,
+		},
+	}
+}
+
+func (r *Reconciliation) trustSets(desired []TrustLineState, lines data.AccountLineSlice) []data.Transaction {
+	var txs []data.Transaction
+	for _, state := range desired {
+		var (
+			line  *data.AccountLine
+			name  = fmt.Sprintf("TrustLines[%s/%s]", state.Limit.Currency, state.Limit.Issuer)
+			flags data.TransactionFlag
+			tx    = &data.TrustSet{
+				TxBase: data.TxBase{
+					TransactionType: data.TRUST_SET,
+					Account:         r.Account,
+				},
+				LimitAmount: state.Limit,
+			}
+			changed bool
+		)
+		for i := range lines {
+			if lines[i].Account == state.Limit.Issuer && lines[i].Currency.Equals(state.Limit.Currency) {
+				line = &lines[i]
+				break
+			}
+		}
+		if line == nil {
+			line = &data.AccountLine{}
+			if !state.Limit.IsZero() {
+				r.differ(name, "none", state.Limit.Value)
+				changed = true
+			}
+		} else if !line.Limit.Value.Equals(*state.Limit.Value) {
+			r.differ(name+".Limit", line.Limit.Value, state.Limit.Value)
+			changed = true
+		}
+		if state.NoRipple != nil && *state.NoRipple != line.NoRipple {
+			r.differ(name+".NoRipple", line.NoRipple, *state.NoRipple)
+			flags |= choose(*state.NoRipple, data.TxSetNoRipple, data.TxClearNoRipple)
+		}
+		if state.Freeze != nil && *state.Freeze != line.Freeze {
+			r.differ(name+".Freeze", line.Freeze, *state.Freeze)
+			flags |= choose(*state.Freeze, data.TxSetFreeze, data.TxClearFreeze)
+		}
+		if state.QualityIn != nil && *state.QualityIn != line.QualityIn {
+			r.differ(name+".QualityIn", line.QualityIn, *state.QualityIn)
+			tx.QualityIn = state.QualityIn
+		}
+		if state.QualityOut != nil && *state.QualityOut != line.QualityOut {
+			r.differ(name+".QualityOut", line.QualityOut, *state.QualityOut)
+			tx.QualityOut = state.QualityOut
+		}
+		if flags != 0 {
+			tx.Flags = &flags
+		}
+		if changed || flags != 0 || tx.QualityIn != nil || tx.QualityOut != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
+func choose(b bool, set, clear data.TransactionFlag) data.TransactionFlag {
+	if b {
+		return set
+	}
+	return clear
+}
+
+func (r *Reconciliation) signerList(client websockets.Client, desired *SignerListState) (*data.SignerListSet, error) {
+	objects, err := client.AccountObjects(r.Account, "signer_list", "current")
+	if err != nil {
+		return nil, err
+	}
+	current := &SignerListState{}
+	for _, le := range objects.AccountObjects {
+		if list, ok := le.(*data.SignerList); ok && list.SignerQuorum != nil {
+			current.SignerQuorum = *list.SignerQuorum
+			for _, entry := range list.SignerEntries {
+				if entry.SignerEntry.Account != nil && entry.SignerEntry.SignerWeight != nil {
+					current.SignerEntries = append(current.SignerEntries, SignerState{
+						Account:      *entry.SignerEntry.Account,
+						SignerWeight: *entry.SignerEntry.SignerWeight,
+					})
+				}
+			}
+		}
+	}
+	if current.String() == desired.String() {
+		return nil, nil
+	}
+	r.differ("SignerList", current, desired)
+	tx := &data.SignerListSet{
+		TxBase: data.TxBase{
+			TransactionType: data.SIGNER_LIST_SET,
+			Account:         r.Account,
+		},
+		SignerQuorum: desired.SignerQuorum,
+	}
+	if desired.SignerQuorum == 0 {
+		return tx, nil
+	}
+	for i := range desired.SignerEntries {
+		entry := desired.SignerEntries[i]
+		tx.SignerEntries = append(tx.SignerEntries, data.SignerEntry{
+			SignerEntry: data.SignerEntryItem{
+				Account:      &entry.Account,
+				SignerWeight: &entry.SignerWeight,
+			},
+		})
+	}
+	return tx, nil
+}
+
+// Describes the signer list with its entries in a canonical order
+func (s *SignerListState) String() string {
+	if s.SignerQuorum == 0 {
+		return "none"
+	}
+	entries := make([]string, len(s.SignerEntries))
+	for i, entry := range s.SignerEntries {
+		entries[i] = fmt.Sprintf("%s=%d", entry.Account, entry.SignerWeight)
+	}
+	sort.Strings(entries)
+	return fmt.Sprintf("quorum %d [%s]", s.SignerQuorum, strings.Join(entries, " "))
+}
+
+func (r *Reconciliation) regularKey(current *data.RegularKey, desired string) (*data.SetRegularKey, error) {
+	var currentAddress string
+	if current != nil {
+		currentAddress = current.String()
+	}
+	if currentAddress == desired {
+		return nil, nil
+	}
+	r.differ("RegularKey", quote(currentAddress), quote(desired))
+	tx := &data.SetRegularKey{
+		TxBase: data.TxBase{
+			TransactionType: data.SET_REGULAR_KEY,
+			Account:         r.Account,
+		},
+	}
+	if desired != "" {
+		key, err := data.NewRegularKeyFromAddress(desired)
+		if err != nil {
+			return nil, err
+		}
+		tx.RegularKey = key
+	}
+	return tx, nil
+}
+
+func quote(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return fmt.Sprintf("%q", s)
+}
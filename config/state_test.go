@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// Responds to each JSON-RPC method with a canned result
+type fakeRippled map[string]map[string]interface{}
+
+func (f fakeRippled) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var request struct {
+		Method string
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := map[string]interface{}{"status": "success"}
+	for k, v := range f[request.Method] {
+		result[k] = v
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+}
+
+func TestReconcile(t *testing.T) {
+	server := httptest.NewServer(fakeRippled{
+		"account_info": {"account_data": map[string]interface{}{
+			"LedgerEntryType": "AccountRoot",
+			"Account":         "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+			"Sequence":        7,
+			"Flags":           uint32(data.LsRequireAuth),
+			"Domain":          hex.EncodeToString([]byte("old.example")),
+			"TransferRate":    1000000000,
+		}},
+		"account_lines": {"lines": []interface{}{
+			map[string]interface{}{
+				"account":    "rb1fWuuAEtPUaeEWxocV3h4x5JwDTFZzH",
+				"balance":    "0",
+				"currency":   "USD",
+				"limit":      "100",
+				"limit_peer": "0",
+			},
+			map[string]interface{}{
+				"account":    "rb2L6Ujzku4hQWiCYyJQZJD9A1qEsUz5g",
+				"balance":    "5",
+				"currency":   "EUR",
+				"limit":      "50",
+				"limit_peer": "0",
+			},
+		}},
+		"account_objects": {"account_objects": []interface{}{}},
+	})
+	defer server.Close()
+	client := websockets.NewRPCClient(server.URL, nil)
+
+	f, err := os.Open("testdata/accounts.json")
+	if err != nil {
+		t.Fatalf("open file: %v", err)
+	}
+	defer f.Close()
+	states, err := ParseAccounts(f)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	r, err := states[0].Reconcile(client)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(r.Differences) != 9 {
+		t.Errorf("differences: %v", r.Differences)
+	}
+	var types []string
+	for _, tx := range r.Action.Transactions {
+		types = append(types, tx.GetTransactionType().String())
+	}
+	expected := "SetRegularKey,SignerListSet,AccountSet,AccountSet,TrustSet,TrustSet,AccountSet"
+	if got := strings.Join(types, ","); got != expected {
+		t.Fatalf("transactions: %s", got)
+	}
+	first := r.Action.Transactions[2].(*data.AccountSet)
+	if first.Domain == nil || string(*first.Domain) != "example.com" || first.TransferRate != nil {
+		t.Errorf("first AccountSet: %s", js(first))
+	}
+	if *first.SetFlag != uint32(data.TxDefaultRipple) || *first.ClearFlag != uint32(data.TxSetRequireAuth) {
+		t.Errorf("first AccountSet flags: %s", js(first))
+	}
+	if last := r.Action.Transactions[6].(*data.AccountSet); *last.SetFlag != uint32(data.TxSetDisableMaster) {
+		t.Errorf("last AccountSet: %s", js(last))
+	}
+	if usd := r.Action.Transactions[4].(*data.TrustSet); *usd.Flags != data.TxSetNoRipple || usd.LimitAmount.Currency.String() != "USD" {
+		t.Errorf("USD TrustSet: %s", js(usd))
+	}
+	actions := ActionSlice{r.Action}
+	if err := actions.Prepare(); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+
+	// Already in the desired state
+	r, err = states[1].Reconcile(client)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(r.Differences) != 0 || len(r.Action.Transactions) != 0 {
+		t.Errorf("unexpected changes: %v", r.Differences)
+	}
+}
+
+func TestReconcileMasterDisabled(t *testing.T) {
+	server := httptest.NewServer(fakeRippled{
+		"account_info": {"account_data": map[string]interface{}{
+			"LedgerEntryType": "AccountRoot",
+			"Account":         "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+			"Sequence":        7,
+			"Flags":           uint32(data.LsDisableMaster),
+		}},
+	})
+	defer server.Close()
+	client := websockets.NewRPCClient(server.URL, nil)
+	seed, err := data.NewSeedFromAddress("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing to sign
+	state := AccountState{Seed: *seed, Flags: map[string]bool{"DisableMaster": true}}
+	if _, err := state.Reconcile(client); err != nil {
+		t.Errorf("reconcile: %v", err)
+	}
+	domain := "example.com"
+	state.Domain = &domain
+	if _, err := state.Reconcile(client); err == nil || !strings.Contains(err.Error(), "master key is disabled") {
+		t.Errorf("reconcile: %v", err)
+	}
+	state = AccountState{Seed: *seed, Flags: map[string]bool{"DisableMaster": false}}
+	if _, err := state.Reconcile(client); err == nil || !strings.Contains(err.Error(), "DisableMaster must be cleared with the regular key") {
+		t.Errorf("reconcile: %v", err)
+	}
+}
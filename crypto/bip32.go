@@ -0,0 +1,155 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Added to an index for hardened derivation
+const BIP32Hardened uint32 = 0x80000000
+
+// The BIP44 coin type of XRP
+const XRPCoinType uint32 = 144
+
+// A secp256k1 key in a BIP32 hierarchy. As a Key, the sequence is the index
+// of a non-hardened child, so that the key for m/44'/144'/0'/0 with sequence
+// 3 is the key at m/44'/144'/0'/0/3. A nil sequence is the key itself.
+type bip32Key struct {
+	key       *btcec.PrivateKey
+	chainCode []byte
+}
+
+// NewBIP32Key returns the master key of the seed, ie. from MnemonicSeed
+func NewBIP32Key(seed []byte) (*bip32Key, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("bip32: bad seed length: %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	return newBIP32Key(mac.Sum(nil))
+}
+
+// NewBIP44Key returns the key for m/44'/144'/account'/0 which hardware
+// wallets derive XRPL accounts from. Use sequence 0 for the first account.
+func NewBIP44Key(mnemonic, passphrase string, account uint32) (*bip32Key, error) {
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	master, err := NewBIP32Key(seed)
+	if err != nil {
+		return nil, err
+	}
+	return master.Derive(fmt.Sprintf("m/44'/%d'/%d'/0", XRPCoinType, account))
+}
+
+func newBIP32Key(i []byte) (*bip32Key, error) {
+	k := new(big.Int).SetBytes(i[:32])
+	if k.Sign() == 0 || k.Cmp(order) >= 0 {
+		return nil, fmt.Errorf("bip32: invalid key")
+	}
+	key, _ := btcec.PrivKeyFromBytes(i[:32])
+	return &bip32Key{
+		key:       key,
+		chainCode: i[32:],
+	}, nil
+}
+
+// Child derives the private child key at index, which is hardened if it
+// includes BIP32Hardened. An error, which is extremely unlikely, means the
+// next index should be used instead.
+func (k *bip32Key) Child(index uint32) (*bip32Key, error) {
+	data := make([]byte, 0, 37)
+	if index >= BIP32Hardened {
+		data = append(data, 0)
+		data = append(data, k.privateBytes()...)
+	} else {
+		data = append(data, k.key.PubKey().SerializeCompressed()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	i := mac.Sum(nil)
+	il := new(big.Int).SetBytes(i[:32])
+	if il.Cmp(order) >= 0 {
+		return nil, fmt.Errorf("bip32: invalid child %d", index)
+	}
+	il.Add(il, new(big.Int).SetBytes(k.privateBytes()))
+	il.Mod(il, order)
+	il.FillBytes(i[:32])
+	return newBIP32Key(i)
+}
+
+// Derive follows a path such as "m/44'/144'/0'/0/0" from this key, which
+// is taken to be m
+func (k *bip32Key) Derive(path string) (*bip32Key, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("bip32: path must start with m: %s", path)
+	}
+	key := k
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			part, offset = part[:len(part)-1], BIP32Hardened
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= BIP32Hardened {
+			return nil, fmt.Errorf("bip32: bad path: %s", path)
+		}
+		if key, err = key.Child(uint32(index) + offset); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (k *bip32Key) privateBytes() []byte {
+	b := k.key.Key.Bytes()
+	return b[:]
+}
+
+// Returns nil for a hardened sequence, or in the extremely unlikely case
+// that the child is invalid, so that signing with it fails
+func (k *bip32Key) child(sequence *uint32) *bip32Key {
+	if sequence == nil {
+		return k
+	}
+	if *sequence >= BIP32Hardened {
+		return nil
+	}
+	child, err := k.Child(*sequence)
+	if err != nil {
+		return nil
+	}
+	return child
+}
+
+func (k *bip32Key) Id(sequence *uint32) []byte {
+	public := k.Public(sequence)
+	if public == nil {
+		return nil
+	}
+	return Sha256RipeMD160(public)
+}
+
+func (k *bip32Key) Private(sequence *uint32) []byte {
+	if child := k.child(sequence); child != nil {
+		return child.privateBytes()
+	}
+	return nil
+}
+
+func (k *bip32Key) Public(sequence *uint32) []byte {
+	if child := k.child(sequence); child != nil {
+		return child.key.PubKey().SerializeCompressed()
+	}
+	return nil
+}
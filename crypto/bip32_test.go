@@ -0,0 +1,79 @@
+package crypto
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type BIP32Suite struct{}
+
+var _ = Suite(&BIP32Suite{})
+
+// Test vector 1 from BIP32
+var bip32Tests = []struct {
+	path      string
+	private   string
+	chainCode string
+}{
+	{"m", "E8F32E723DECF4051AEFAC8E2C93C9C5B214313817CDB01A1494B917C8436B35", "873DFF81C02F525623FD1FE5167EAC3A55A049DE3D314BB42EE227FFED37D508"},
+	{"m/0'", "EDB2E14F9EE77D26DD93B4ECEDE8D16ED408CE149B6CD80B0715A2D911A0AFEA", "47FDACBD0F1097043B78C63C20C34EF4ED9A111D980047AD16282C7AE6236141"},
+	{"m/0'/1", "3C6CB8D0F6A264C91EA8B5030FADAA8E538B020F0A387421A12DE9319DC93368", "2A7857631386BA23DACAC34180DD1983734E444FDBF774041578E9B6ADB37C19"},
+}
+
+func (s *BIP32Suite) TestDerive(c *C) {
+	master, err := NewBIP32Key(h2b("000102030405060708090A0B0C0D0E0F"))
+	c.Assert(err, IsNil)
+	for _, test := range bip32Tests {
+		key, err := master.Derive(test.path)
+		c.Assert(err, IsNil)
+		c.Check(b2h(key.Private(nil)), Equals, test.private, Commentf("%s", test.path))
+		c.Check(b2h(key.chainCode), Equals, test.chainCode, Commentf("%s", test.path))
+	}
+	_, err = master.Derive("0/1")
+	c.Assert(err, ErrorMatches, "bip32: path must start with m.*")
+	_, err = master.Derive("m/x")
+	c.Assert(err, ErrorMatches, "bip32: bad path.*")
+}
+
+func (s *BIP32Suite) TestMnemonic(c *C) {
+	mnemonic, err := NewMnemonic(h2b("80808080808080808080808080808080"))
+	c.Assert(err, IsNil)
+	c.Assert(mnemonic, Equals, "letter advice cage absurd amount doctor acoustic avoid letter advice cage above")
+	entropy, err := MnemonicEntropy(mnemonic)
+	c.Assert(err, IsNil)
+	c.Assert(b2h(entropy), Equals, "80808080808080808080808080808080")
+	mnemonic, err = NewMnemonic(h2b("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"))
+	c.Assert(err, IsNil)
+	c.Assert(mnemonic, Equals, "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote")
+
+	// From the BIP39 test vectors, which all use the passphrase TREZOR
+	seed, err := MnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "TREZOR")
+	c.Assert(err, IsNil)
+	c.Assert(b2h(seed), Equals, "C55257C360C07C72029AEBC1B53C05ED0362ADA38EAD3E3E9EFA3708E53495531F09A6987599D18264C1E1C92F2CF141630C7A3C4AB7C81B2F001698E7463B04")
+
+	_, err = MnemonicEntropy("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	c.Assert(err, ErrorMatches, "bip39: bad checksum")
+	_, err = MnemonicEntropy("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abut")
+	c.Assert(err, ErrorMatches, "bip39: unknown word: abut")
+	_, err = MnemonicSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "Motörhead")
+	c.Assert(err, ErrorMatches, "bip39: passphrase must be ASCII")
+}
+
+func (s *BIP32Suite) TestBIP44(c *C) {
+	key, err := NewBIP44Key("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", 0)
+	c.Assert(err, IsNil)
+	id, err := AccountId(key, new(uint32))
+	c.Assert(err, IsNil)
+	c.Assert(id.String(), Equals, "rHsMGQEkVNJmpGWs8XUBoTBiAAbwxZN5v3")
+	sequence := uint32(0)
+	c.Assert(checkSignature(c, key.Private(&sequence), key.Public(&sequence), Sha512Half([]byte("hello")), nil), Equals, true)
+	sequence = 1
+	c.Assert(b2h(key.Public(&sequence)), Not(Equals), b2h(key.Public(new(uint32))))
+
+	// Hardened sequences have no key rather than panicking
+	sequence = BIP32Hardened
+	c.Assert(key.Private(&sequence), IsNil)
+	c.Assert(key.Public(&sequence), IsNil)
+	c.Assert(key.Id(&sequence), IsNil)
+	_, err = Sign(key.Private(&sequence), Sha512Half([]byte("hello")), nil)
+	c.Assert(err, NotNil)
+}
@@ -12,12 +12,13 @@ const (
 const (
 	ALPHABET = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
 
-	RIPPLE_ACCOUNT_ID      HashVersion = 0
-	RIPPLE_NODE_PUBLIC     HashVersion = 28
-	RIPPLE_NODE_PRIVATE    HashVersion = 32
-	RIPPLE_FAMILY_SEED     HashVersion = 33
-	RIPPLE_ACCOUNT_PRIVATE HashVersion = 34
-	RIPPLE_ACCOUNT_PUBLIC  HashVersion = 35
+	RIPPLE_ACCOUNT_ID       HashVersion = 0
+	RIPPLE_NODE_PUBLIC      HashVersion = 28
+	RIPPLE_NODE_PRIVATE     HashVersion = 32
+	RIPPLE_FAMILY_SEED      HashVersion = 33
+	RIPPLE_ACCOUNT_PRIVATE  HashVersion = 34
+	RIPPLE_ACCOUNT_PUBLIC   HashVersion = 35
+	RIPPLE_FAMILY_GENERATOR HashVersion = 41
 )
 
 var hashTypes = [...]struct {
@@ -26,10 +27,11 @@ var hashTypes = [...]struct {
 	Payload           int
 	MaximumCharacters int
 }{
-	RIPPLE_ACCOUNT_ID:      {"Short name for sending funds to an account.", 'r', 20, 35},
-	RIPPLE_NODE_PUBLIC:     {"Validation public key for node.", 'n', 33, 53},
-	RIPPLE_NODE_PRIVATE:    {"Validation private key for node.", 'p', 32, 52},
-	RIPPLE_FAMILY_SEED:     {"Family seed.", 's', 16, 29},
-	RIPPLE_ACCOUNT_PRIVATE: {"Account private key.", 'p', 32, 52},
-	RIPPLE_ACCOUNT_PUBLIC:  {"Account public key.", 'a', 33, 53},
+	RIPPLE_ACCOUNT_ID:       {"Short name for sending funds to an account.", 'r', 20, 35},
+	RIPPLE_NODE_PUBLIC:      {"Validation public key for node.", 'n', 33, 53},
+	RIPPLE_NODE_PRIVATE:     {"Validation private key for node.", 'p', 32, 52},
+	RIPPLE_FAMILY_SEED:      {"Family seed.", 's', 16, 29},
+	RIPPLE_ACCOUNT_PRIVATE:  {"Account private key.", 'p', 32, 52},
+	RIPPLE_ACCOUNT_PUBLIC:   {"Account public key.", 'a', 33, 53},
+	RIPPLE_FAMILY_GENERATOR: {"Family public generator.", 'f', 33, 53},
 }
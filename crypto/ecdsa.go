@@ -3,6 +3,7 @@ package crypto
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"fmt"
 	"math/big"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -41,11 +42,28 @@ func NewECDSAKey(seed []byte) (*ecdsaKey, error) {
 	return &ecdsaKey{newKey(seed)}, nil
 }
 
-func (k *ecdsaKey) generateKey(sequence uint32) *btcec.PrivateKey {
+// The private key which is added to the root key to make the key for
+// sequence. It only depends on the root public key.
+func sequenceKey(root *btcec.PublicKey, sequence uint32) *btcec.PrivateKey {
 	seed := make([]byte, btcec.PubKeyBytesLenCompressed+4)
-	copy(seed, k.PubKey().SerializeCompressed())
+	copy(seed, root.SerializeCompressed())
 	binary.BigEndian.PutUint32(seed[btcec.PubKeyBytesLenCompressed:], sequence)
-	key := newKey(seed)
+	return newKey(seed)
+}
+
+// NewECDSAKeyFromPrivate makes a key from a raw private key, such as a
+// validator's ephemeral key, rather than from a family seed
+func NewECDSAKeyFromPrivate(private []byte) (*ecdsaKey, error) {
+	d := new(big.Int).SetBytes(private)
+	if len(private) > btcec.PrivKeyBytesLen || d.Cmp(zero) == 0 || d.Cmp(order) >= 0 {
+		return nil, fmt.Errorf("Bad ECDSA private key")
+	}
+	key, _ := btcec.PrivKeyFromBytes(private)
+	return &ecdsaKey{key}, nil
+}
+
+func (k *ecdsaKey) generateKey(sequence uint32) *btcec.PrivateKey {
+	key := sequenceKey(k.PubKey(), sequence)
 	key.Key = *key.Key.Add(&k.Key)
 	return &btcec.PrivateKey{
 		Key: key.Key,
@@ -61,7 +79,8 @@ func (k *ecdsaKey) Id(sequence *uint32) []byte {
 
 func (k *ecdsaKey) Private(sequence *uint32) []byte {
 	if sequence == nil {
-		return k.ToECDSA().D.Bytes()
+		b := k.Key.Bytes()
+		return b[:]
 	}
 	b := k.generateKey(*sequence).Key.Bytes()
 	return b[:]
@@ -73,3 +92,45 @@ func (k *ecdsaKey) Public(sequence *uint32) []byte {
 	}
 	return k.generateKey(*sequence).PubKey().SerializeCompressed()
 }
+
+// A watch-only ECDSA key made from a root public key, the family generator,
+// which derives the same public keys and account ids as the ecdsaKey it
+// belongs to. It has no private key.
+type publicGenerator struct {
+	*btcec.PublicKey
+}
+
+// NewPublicGenerator accepts the root public key of an ECDSA key, ie. the
+// payload of a family generator or the account public key for a nil sequence
+func NewPublicGenerator(public []byte) (*publicGenerator, error) {
+	key, err := btcec.ParsePubKey(public)
+	if err != nil {
+		return nil, fmt.Errorf("Bad public generator: %s", err)
+	}
+	return &publicGenerator{key}, nil
+}
+
+func (k *publicGenerator) generateKey(sequence uint32) *btcec.PublicKey {
+	var root, offset, sum btcec.JacobianPoint
+	k.AsJacobian(&root)
+	sequenceKey(k.PublicKey, sequence).PubKey().AsJacobian(&offset)
+	btcec.AddNonConst(&root, &offset, &sum)
+	sum.ToAffine()
+	return btcec.NewPublicKey(&sum.X, &sum.Y)
+}
+
+func (k *publicGenerator) Id(sequence *uint32) []byte {
+	return Sha256RipeMD160(k.Public(sequence))
+}
+
+// Private is always nil
+func (k *publicGenerator) Private(sequence *uint32) []byte {
+	return nil
+}
+
+func (k *publicGenerator) Public(sequence *uint32) []byte {
+	if sequence == nil {
+		return k.SerializeCompressed()
+	}
+	return k.generateKey(*sequence).SerializeCompressed()
+}
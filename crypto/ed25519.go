@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/ed25519"
 	"crypto/rand"
+	"fmt"
 )
 
 type ed25519key struct {
@@ -42,3 +43,12 @@ func NewEd25519Key(seed []byte) (*ed25519key, error) {
 	}
 	return &ed25519key{priv: priv}, nil
 }
+
+// NewEd25519KeyFromSecret makes a key from the 32 byte secret which
+// rippled encodes as a node or account private key
+func NewEd25519KeyFromSecret(secret []byte) (*ed25519key, error) {
+	if len(secret) != ed25519.SeedSize {
+		return nil, fmt.Errorf("Wrong ed25519 secret length: %d", len(secret))
+	}
+	return &ed25519key{priv: ed25519.NewKeyFromSeed(secret)}, nil
+}
@@ -60,6 +60,10 @@ func NewFamilySeed(b []byte) (Hash, error) {
 	return newHash(b, RIPPLE_FAMILY_SEED)
 }
 
+func NewFamilyGenerator(b []byte) (Hash, error) {
+	return newHash(b, RIPPLE_FAMILY_GENERATOR)
+}
+
 func AccountId(key Key, sequence *uint32) (Hash, error) {
 	return NewAccountId(key.Id(sequence))
 }
@@ -72,6 +76,12 @@ func AccountPrivateKey(key Key, sequence *uint32) (Hash, error) {
 	return NewAccountPrivateKey(key.Private(sequence))
 }
 
+// FamilyGenerator is the root public key of an ECDSA key, from which
+// NewPublicGenerator can derive the same account public keys
+func FamilyGenerator(key Key) (Hash, error) {
+	return NewFamilyGenerator(key.Public(nil))
+}
+
 func NodePublicKey(key Key) (Hash, error) {
 	return NewNodePublicKey(key.Public(nil))
 }
@@ -107,3 +107,49 @@ func (s *KeySuite) TestEd25119(c *C) {
 	c.Check(checkSignature(c, key.Private(nil), other.Public(nil), hash, msg), Equals, false)
 	c.Check(checkSignature(c, other.Private(nil), key.Public(nil), hash, msg), Equals, false)
 }
+
+func (s *KeySuite) TestPublicGenerator(c *C) {
+	seed, err := GenerateFamilySeed("masterpassphrase")
+	c.Check(err, IsNil)
+	key, err := NewECDSAKey(seed.Payload())
+	c.Check(err, IsNil)
+	generator, err := FamilyGenerator(key)
+	c.Check(err, IsNil)
+	c.Check(generator.String(), Equals, "fhuJKrhSDzV2SkjLn9qbwm5AaRmrxDPfFsHDCP6yfDZWcxDFz4mt")
+
+	parsed, err := NewRippleHashCheck(generator.String(), RIPPLE_FAMILY_GENERATOR)
+	c.Check(err, IsNil)
+	watch, err := NewPublicGenerator(parsed.Payload())
+	c.Check(err, IsNil)
+	c.Check(watch.Public(nil), DeepEquals, key.Public(nil))
+	for sequence := uint32(0); sequence < 5; sequence++ {
+		c.Check(checkHash(AccountId(watch, &sequence)), Equals, checkHash(AccountId(key, &sequence)))
+		c.Check(checkHash(AccountPublicKey(watch, &sequence)), Equals, checkHash(AccountPublicKey(key, &sequence)))
+	}
+	c.Check(watch.Private(nil), IsNil)
+	_, err = Sign(watch.Private(new(uint32)), Sha512Half([]byte("Hello, nurse!")), nil)
+	c.Check(err, NotNil)
+	_, err = NewPublicGenerator(h2b("0102"))
+	c.Check(err, ErrorMatches, "Bad public generator.*")
+}
+
+func (s *KeySuite) TestECDSAPrivateKeyLength(c *C) {
+	// A private key with a leading zero byte is still 32 bytes long
+	private := h2b("00E8E8A9AAB3C1A5A9ECE6FD1A57CB8B4A3DAC6EDE11E5B3BA0E3D8A1BA3EA88")
+	key, err := NewECDSAKeyFromPrivate(private)
+	c.Assert(err, IsNil)
+	c.Check(key.Private(nil), DeepEquals, private)
+	msg := []byte("Hello, nurse!")
+	c.Check(checkSignature(c, key.Private(nil), key.Public(nil), Sha512Half(msg), msg), Equals, true)
+}
+
+func (s *KeySuite) TestAccountSequence(c *C) {
+	seed, err := GenerateFamilySeed("masterpassphrase")
+	c.Assert(err, IsNil)
+	ecdsa, err := NewECDSAKey(seed.Payload())
+	c.Assert(err, IsNil)
+	c.Check(*AccountSequence(ecdsa), Equals, uint32(0))
+	ed, err := NewEd25519Key(seed.Payload())
+	c.Assert(err, IsNil)
+	c.Check(AccountSequence(ed), IsNil)
+}
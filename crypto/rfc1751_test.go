@@ -0,0 +1,58 @@
+package crypto
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type EncodingSuite struct{}
+
+var _ = Suite(&EncodingSuite{})
+
+// From RFC1751
+var rfc1751Tests = []struct {
+	key   string
+	words string
+}{
+	{"EB33F77EE73D4053", "TIDE ITCH SLOW REIN RULE MOT"},
+	{"CCAC2AED591056BE4F90FD441C534766", "RASH BUSH MILK LOOK BAD BRIM AVID GAFF BAIT ROT POD LOVE"},
+	{"EFF81F9BFBC65350920CDD7416DE8009", "TROD MUTE TAIL WARM CHAR KONG HAAG CITY BORE O TEAL AWL"},
+}
+
+func (s *EncodingSuite) TestRFC1751(c *C) {
+	for _, test := range rfc1751Tests {
+		words, err := RFC1751Encode(h2b(test.key))
+		c.Assert(err, IsNil)
+		c.Assert(words, Equals, test.words)
+		key, err := RFC1751Decode(" " + test.words + "\n")
+		c.Assert(err, IsNil)
+		c.Assert(b2h(key), Equals, test.key)
+	}
+	_, err := RFC1751Decode("tide itch slow rein rule mot")
+	c.Assert(err, IsNil)
+	_, err = RFC1751Decode("TIED ITCH SLOW REIN RULE MOT")
+	c.Assert(err, ErrorMatches, "rfc1751: parity error in words 1 to 6")
+	_, err = RFC1751Decode("TIDE ITCH SLOW REIN RULE SPLAT")
+	c.Assert(err, ErrorMatches, "rfc1751: unknown word: SPLAT")
+	_, err = RFC1751Decode("TIDE ITCH SLOW REIN RULE")
+	c.Assert(err, ErrorMatches, "rfc1751: 5 words is not a multiple of 6")
+	_, err = RFC1751Encode(h2b("EB33F77EE73D40"))
+	c.Assert(err, NotNil)
+}
+
+func (s *EncodingSuite) TestSecretNumbers(c *C) {
+	entropy := h2b("0000FFFF0102030405060708090A0B0C")
+	numbers, err := SecretNumbersEncode(entropy)
+	c.Assert(err, IsNil)
+	c.Assert(numbers, Equals, "000000 655350 002583 007724 012860 018000 023144 028283")
+	for _, s := range []string{numbers, "000000-655350-002583-007724-012860-018000-023144-028283", "000000655350002583007724012860018000023144028283"} {
+		decoded, err := SecretNumbersDecode(s)
+		c.Assert(err, IsNil)
+		c.Assert(decoded, DeepEquals, entropy)
+	}
+	_, err = SecretNumbersDecode("000000 655351 002583 007724 012860 018000 023144 028283")
+	c.Assert(err, ErrorMatches, "secret numbers: block 2 has a bad checksum: 655351")
+	_, err = SecretNumbersDecode("000000 700000 002583 007724 012860 018000 023144 028283")
+	c.Assert(err, ErrorMatches, "secret numbers: block 2 is out of range: 700000")
+	_, err = SecretNumbersDecode("000000 655350 002583 007724 012860 018000 023144")
+	c.Assert(err, ErrorMatches, "secret numbers: expected 48 digits not 42")
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SecretNumbersEncode converts 16 bytes of entropy to the 8 blocks of 6
+// digits described by XLS-12. Each block is 2 bytes as a 5 digit number
+// followed by a check digit which depends on the block's position.
+func SecretNumbersEncode(entropy []byte) (string, error) {
+	if len(entropy) != 16 {
+		return "", fmt.Errorf("secret numbers: entropy must be 16 bytes not %d", len(entropy))
+	}
+	blocks := make([]string, 8)
+	for i := range blocks {
+		value := int(binary.BigEndian.Uint16(entropy[i*2:]))
+		blocks[i] = fmt.Sprintf("%05d%d", value, secretNumbersChecksum(i, value))
+	}
+	return strings.Join(blocks, " "), nil
+}
+
+// SecretNumbersDecode converts 8 blocks of 6 digits back to entropy. The
+// blocks may be separated by whitespace or dashes, or not at all.
+func SecretNumbersDecode(s string) ([]byte, error) {
+	digits := strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '\t' || r == '\n'
+	}), "")
+	if len(digits) != 48 {
+		return nil, fmt.Errorf("secret numbers: expected 48 digits not %d", len(digits))
+	}
+	entropy := make([]byte, 16)
+	for i := 0; i < 8; i++ {
+		block := digits[i*6 : i*6+6]
+		n, err := strconv.Atoi(block)
+		if err != nil || strings.Trim(block, "0123456789") != "" {
+			return nil, fmt.Errorf("secret numbers: block %d is not a number: %s", i+1, block)
+		}
+		value, check := n/10, n%10
+		if value > 0xFFFF {
+			return nil, fmt.Errorf("secret numbers: block %d is out of range: %s", i+1, block)
+		}
+		if secretNumbersChecksum(i, value) != check {
+			return nil, fmt.Errorf("secret numbers: block %d has a bad checksum: %s", i+1, block)
+		}
+		binary.BigEndian.PutUint16(entropy[i*2:], uint16(value))
+	}
+	return entropy, nil
+}
+
+func secretNumbersChecksum(position, value int) int {
+	return value * (position*2 + 1) % 9
+}
@@ -0,0 +1,51 @@
+package crypto
+
+// Signer signs on behalf of a single key without exposing the private key,
+// which may be held by another process or a hardware device.
+type Signer interface {
+	// The 33 byte public key, prefixed with 0xED for ed25519 keys
+	PublicKey() ([]byte, error)
+	// Returns the signature of the message, or of its hash for secp256k1
+	// keys. The hash is always the first half of the SHA-512 of msg.
+	Sign(hash, msg []byte) ([]byte, error)
+}
+
+type keySigner struct {
+	key      Key
+	sequence *uint32
+}
+
+// AccountSequence is the sequence of a seed's first account key: nil for an
+// ed25519 key, which is the account key itself, and 0 for a secp256k1 root
+// key
+func AccountSequence(key Key) *uint32 {
+	if _, ok := key.(*ed25519key); ok {
+		return nil
+	}
+	return new(uint32)
+}
+
+// NewKeySigner returns a Signer for a key held in memory
+func NewKeySigner(key Key, sequence *uint32) Signer {
+	return &keySigner{
+		key:      key,
+		sequence: sequence,
+	}
+}
+
+func (s *keySigner) PublicKey() ([]byte, error) {
+	return s.key.Public(s.sequence), nil
+}
+
+func (s *keySigner) Sign(hash, msg []byte) ([]byte, error) {
+	return Sign(s.key.Private(s.sequence), hash, msg)
+}
+
+// SignerId returns the account id of the signer's public key
+func SignerId(signer Signer) ([]byte, error) {
+	public, err := signer.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return Sha256RipeMD160(public), nil
+}
@@ -1,8 +1,13 @@
 package data
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"time"
 
+	"github.com/rubblelabs/ripple/crypto"
 	internal "github.com/rubblelabs/ripple/testing"
 	. "gopkg.in/check.v1"
 )
@@ -33,6 +38,83 @@ func (s *CodecSuite) TestParseTransactions(c *C) {
 	}
 }
 
+func (s *CodecSuite) TestTicketSequence(c *C) {
+	ticket := uint32(3)
+	c.Assert(TicketSequence(&Payment{}), IsNil)
+	c.Assert(TicketSequence(&Payment{TicketSequence: &ticket}), Equals, &ticket)
+	c.Assert(TicketSequence(&OfferCancel{TicketSequence: &ticket}), Equals, &ticket)
+	c.Assert(TicketSequence(&Clawback{}), IsNil)
+
+	payment := &Payment{TxBase: TxBase{Sequence: 7}}
+	c.Assert(SetTicketSequence(payment, 4), Equals, true)
+	c.Assert(*payment.TicketSequence, Equals, uint32(4))
+	c.Assert(payment.Sequence, Equals, uint32(0))
+	c.Assert(SetTicketSequence(&Clawback{}, 4), Equals, false)
+}
+
+// From ripple-binary-codec's signing data tests
+const multiSigningTx = `{
+	"TransactionType": "Payment",
+	"Account": "r9LqNeG6qHxjeUocjvVki2XR35weJ9mZgQ",
+	"Destination": "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+	"Amount": "1000",
+	"Fee": "10",
+	"Flags": 2147483648,
+	"Sequence": 1,
+	"SigningPubKey": "",
+	"TxnSignature": "30440220718D264EF05CAED7C781FF6DE298DCAC68D002562C9BF3A07C1E721B420C0DAB02203A5A4779EF4D2CCC7BC3EF886676D803A9981B928D3B8ACA483B80ECA3CD7B9B"
+}`
+
+func (s *CodecSuite) TestMultiSigningHash(c *C) {
+	var tx Payment
+	c.Assert(json.Unmarshal([]byte(multiSigningTx), &tx), IsNil)
+	signer, err := NewAccountFromAddress("rJZdUusLDtY9NEsGea7ijqhVrXv98rYBYN")
+	c.Assert(err, IsNil)
+	hash, msg, err := MultiSigningHash(&tx, *signer)
+	c.Assert(err, IsNil)
+	c.Assert(string(b2h(msg)), Equals, "120000228000000024000000016140000000000003E868400000000000000A730081145B812C9D57731E27A2DA8B1830195F88EF32A3B68314B5F762798A53D543A014CAF8B297CFF8F2F937E8")
+	expected, err := hex.DecodeString("534D5400" + string(b2h(msg)) + "C0A5ABEF242802EFED4B041E8F2D4A8CC86AE3D1")
+	c.Assert(err, IsNil)
+	c.Assert(hash.Bytes(), DeepEquals, crypto.Sha512Half(expected))
+}
+
+// The MultiSigningHash transaction signed for the Ed25519 and secp256k1
+// accounts of snoPBrXtMeMyMHUVTgbuqAfg1SUTb
+const multiSignedTx = "120000228000000024000000016140000000000003E868400000000000000A730081145B812C9D57731E27A2DA8B1830195F88EF32A3B68314B5F762798A53D543A014CAF8B297CFF8F2F937E8F3E0107321EDAAC3F98BB94F451804EF5993C847DAAA4E6154F455635659D88AA5C80F15630374404D1D2312DA430D98638FBA205CF937EF0CC9BC5839908752B27F88203A07832CBE501A5900D4A8567D73EF641B996726CA0DDFD0AE264FA7172A2CA61D0403038114AA066C988C712815CC37AF71472B7CBBBD4E2A0AE1E01073210330E7FC9D56BB25D6893BA3F317AE5BCF33B3291BD63DB32654A313222F7FD0207446304402205126C1D7EA476E09833E651032CC0E433D88A02761DC4F0981D70DA6A4E75BA40220183EA7CA81E912ECE09ECEE38D9B0A1EF21746F2DF915B3BAB921E3EB37FB4498114B5F762798A53D543A014CAF8B297CFF8F2F937E8E1F1"
+
+func (s *CodecSuite) TestMultiSigned(c *C) {
+	b, err := hex.DecodeString(multiSignedTx)
+	c.Assert(err, IsNil)
+	signed, err := ReadTransaction(bytes.NewReader(b))
+	c.Assert(err, IsNil)
+	tx := signed.(MultiSignable)
+	base := signed.GetBase()
+	c.Assert(base.SigningPubKey.IsZero(), Equals, true)
+	c.Assert(base.TxnSignature, IsNil)
+	c.Assert(base.Signers, HasLen, 2)
+	for _, signer := range base.Signers {
+		hash, msg, err := MultiSigningHash(tx, signer.Signer.Account)
+		c.Assert(err, IsNil)
+		msg = append(append(tx.MultiSigningPrefix().Bytes(), msg...), signer.Signer.Account.Bytes()...)
+		ok, err := crypto.Verify(signer.Signer.SigningPubKey.Bytes(), hash.Bytes(), msg, signer.Signer.TxnSignature.Bytes())
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+	}
+	// Signers are not part of the signing data
+	unsigned, _, err := MultiSigningHash(tx, base.Signers[0].Signer.Account)
+	c.Assert(err, IsNil)
+	signers := base.Signers
+	base.Signers = nil
+	hash, _, err := MultiSigningHash(tx, signers[0].Signer.Account)
+	c.Assert(err, IsNil)
+	c.Assert(hash, Equals, unsigned)
+	base.Signers = signers
+
+	_, raw, err := Raw(tx)
+	c.Assert(err, IsNil)
+	c.Assert(string(b2h(raw)), Equals, multiSignedTx)
+}
+
 func (s *CodecSuite) TestValidations(c *C) {
 	for _, test := range internal.Validations {
 		v, err := ReadValidation(test.Reader())
@@ -47,6 +129,80 @@ func (s *CodecSuite) TestValidations(c *C) {
 	}
 }
 
+func (s *CodecSuite) TestSignValidation(c *C) {
+	ecdsa, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	ed25519, err := crypto.NewEd25519Key(nil)
+	c.Assert(err, IsNil)
+	signingTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, key := range []crypto.Key{ecdsa, ed25519} {
+		v := NewValidation(Hash256{1}, 255, signingTime)
+		v.SetLoadFee(512)
+		v.SetFeeVote(10, 1000000, 200000)
+		v.Amendments = Vector256{{2}, {3}}
+		c.Assert(Sign(v, key, nil), IsNil)
+		c.Assert(v.SigningPubKey.Bytes(), DeepEquals, key.Public(nil))
+		c.Assert(v.Hash.IsZero(), Equals, false)
+		c.Assert(v.Full(), Equals, true)
+		c.Assert(v.SigningTime.Time().Equal(signingTime), Equals, true)
+		ok, err := CheckSignature(v)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+
+		_, raw, err := Raw(v)
+		c.Assert(err, IsNil)
+		read, err := ReadValidation(bytes.NewReader(raw))
+		c.Assert(err, IsNil)
+		c.Assert(read.Flags, Equals, ValidationFull|ValidationCanonicalSignature)
+		c.Assert(*read.LoadFee, Equals, uint32(512))
+		c.Assert(*read.BaseFee, Equals, uint64(10))
+		c.Assert(*read.ReserveBase, Equals, uint32(1000000))
+		c.Assert(*read.ReserveIncrement, Equals, uint32(200000))
+		c.Assert(read.Amendments, DeepEquals, v.Amendments)
+		ok, err = CheckSignature(read)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+		id, err := read.SuppressionId()
+		c.Assert(err, IsNil)
+		c.Assert(id.Bytes(), DeepEquals, crypto.Sha512Half(raw))
+
+		v.SetPartial()
+		c.Assert(v.Full(), Equals, false)
+		ok, _ = CheckSignature(v)
+		c.Assert(ok, Equals, false)
+	}
+}
+
+func (s *CodecSuite) TestSignProposal(c *C) {
+	key, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	p := NewProposal(Hash256{1}, Hash256{2}, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	p.Sequence = 3
+	c.Assert(Sign(p, key, nil), IsNil)
+	c.Assert(p.PublicKey.Bytes(), DeepEquals, key.Public(nil))
+	ok, err := CheckSignature(p)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	signingHash, _, err := SigningHash(p)
+	c.Assert(err, IsNil)
+	c.Assert(p.Hash, Equals, signingHash)
+
+	var expected []byte
+	expected = append(expected, p.LedgerHash[:]...)
+	expected = append(expected, p.PreviousLedger[:]...)
+	expected = append(expected, 0, 0, 0, 3)
+	expected = binary.BigEndian.AppendUint32(expected, p.CloseTime.Uint32())
+	expected = append(append(expected, 33), p.PublicKey[:]...)
+	expected = append(append(expected, byte(len(p.Signature))), p.Signature...)
+	id, err := p.SuppressionId()
+	c.Assert(err, IsNil)
+	c.Assert(id.Bytes(), DeepEquals, crypto.Sha512Half(expected))
+
+	p.Sequence = ProposalBowOut
+	ok, _ = CheckSignature(p)
+	c.Assert(ok, Equals, false)
+}
+
 func (s *CodecSuite) TestParseNodes(c *C) {
 	for _, test := range internal.Nodes {
 		nodeId, err := NewHash256(test.NodeId())
@@ -64,6 +220,47 @@ func (s *CodecSuite) TestParseNodes(c *C) {
 	}
 }
 
+func (s *CodecSuite) TestParseWire(c *C) {
+	for _, test := range internal.Nodes {
+		nodeId, err := NewHash256(test.NodeId())
+		c.Assert(err, IsNil)
+		n, err := ReadPrefix(test.Reader(), *nodeId)
+		c.Assert(err, IsNil)
+		_, value, err := Raw(n)
+		c.Assert(err, IsNil)
+		var wireType WireType
+		switch n.(type) {
+		case *Ledger:
+			continue
+		case *InnerNode:
+			wireType = WT_INNER
+		case LedgerEntry:
+			wireType = WT_ACCOUNT_STATE
+		case *TransactionWithMetaData:
+			wireType = WT_TRANSACTION_WITH_META
+		}
+		w, err := ReadWire(bytes.NewReader(append(value, byte(wireType))), n.NodeType(), n.Ledger(), *nodeId)
+		msg := dump(test, w)
+		c.Assert(err, IsNil, msg)
+		generatedNodeId, err := NodeId(w)
+		c.Assert(err, IsNil, msg)
+		c.Assert(generatedNodeId.String(), Equals, nodeId.String(), msg)
+	}
+	var inner InnerNode
+	inner.Children[3] = Hash256{1}
+	inner.Children[12] = Hash256{2}
+	compressed := append(append(append(inner.Children[3][:], 3), inner.Children[12][:]...), 12, byte(WT_COMPRESSED_INNER))
+	w, err := ReadWire(bytes.NewReader(compressed), NT_ACCOUNT_NODE, 0, zero256)
+	c.Assert(err, IsNil)
+	c.Assert(w.(*InnerNode).Children, DeepEquals, inner.Children)
+	_, err = ReadWire(bytes.NewReader([]byte{1, 2, byte(WT_COMPRESSED_INNER)}), NT_ACCOUNT_NODE, 0, zero256)
+	c.Assert(err, ErrorMatches, "Bad compressed inner node length: 2")
+	_, err = ReadWire(bytes.NewReader(append(compressed[:32], 16, byte(WT_COMPRESSED_INNER))), NT_ACCOUNT_NODE, 0, zero256)
+	c.Assert(err, ErrorMatches, "Bad inner node position: 16")
+	_, err = ReadWire(bytes.NewReader([]byte{9}), NT_ACCOUNT_NODE, 0, zero256)
+	c.Assert(err, ErrorMatches, "Unknown wire type: 9")
+}
+
 func (s *CodecSuite) TestBadNodes(c *C) {
 	for _, test := range internal.BadNodes {
 		nodeid, err := NewHash256(test.NodeId())
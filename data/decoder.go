@@ -1,26 +1,45 @@
 package data
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 )
 
-// ReadWire parses types received via the peer network
+// ReadWire parses nodes received via the peer network, which are followed
+// by their wire type
 func ReadWire(r Reader, typ NodeType, ledgerSequence uint32, nodeId Hash256) (Hashable, error) {
-	version, err := readHashPrefix(r)
-	if err != nil {
+	b := make([]byte, r.Len())
+	if _, err := io.ReadFull(r, b); err != nil {
 		return nil, err
 	}
-	switch version {
-	case HP_LEAF_NODE:
+	if len(b) == 0 {
+		return nil, fmt.Errorf("Empty wire node")
+	}
+	wireType, b := WireType(b[len(b)-1]), b[:len(b)-1]
+	r = bytes.NewReader(b)
+	switch wireType {
+	case WT_TRANSACTION:
+		return ReadTransaction(r)
+	case WT_ACCOUNT_STATE:
 		return ReadLedgerEntry(r, nodeId)
-	case HP_TRANSACTION_NODE:
+	case WT_TRANSACTION_WITH_META:
 		return readTransactionWithMetadata(r, ledgerSequence, nodeId)
-	case HP_INNER_NODE:
+	case WT_INNER:
+		if len(b) != len(InnerNode{}.Children)*len(Hash256{}) {
+			return nil, fmt.Errorf("Bad inner node length: %d", len(b))
+		}
+		return readInnerNode(r, typ, nodeId)
+	case WT_COMPRESSED_INNER:
+		if len(b)%binary.Size(CompressedNodeEntry{}) != 0 {
+			return nil, fmt.Errorf("Bad compressed inner node length: %d", len(b))
+		}
 		return readCompressedInnerNode(r, typ, nodeId)
 	default:
-		return nil, fmt.Errorf("Unknown hash prefix: %s", version.String())
+		return nil, fmt.Errorf("Unknown wire type: %d", wireType)
 	}
 }
 
@@ -84,6 +103,20 @@ func ReadValidation(r Reader) (*Validation, error) {
 	return validation, nil
 }
 
+func ReadManifest(r Reader) (*Manifest, error) {
+	manifest := new(Manifest)
+	v := reflect.ValueOf(manifest)
+	if err := readObject(r, &v); err != nil {
+		return nil, err
+	}
+	hash, _, err := Raw(manifest)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Hash = hash
+	return manifest, nil
+}
+
 func ReadTransaction(r Reader) (Transaction, error) {
 	txType, err := expectType(r, "TransactionType")
 	if err != nil {
@@ -167,6 +200,9 @@ func readCompressedInnerNode(r Reader, typ NodeType, nodeId Hash256) (*InnerNode
 	inner.Type = typ
 	var entry CompressedNodeEntry
 	for read(r, &entry) == nil {
+		if int(entry.Pos) >= len(inner.Children) {
+			return nil, fmt.Errorf("Bad inner node position: %d", entry.Pos)
+		}
 		inner.Children[entry.Pos] = entry.Hash
 	}
 	copy(inner.Id[:], nodeId.Bytes())
@@ -299,10 +335,10 @@ func readObject(r Reader, v *reflect.Value) error {
 				v.Set(s.Elem())
 				return err
 			case "Signer":
-				var signer Signer
+				var signer SignerItem
 				s := reflect.ValueOf(&signer)
 				err := readObject(r, &s)
-				v.Set(s.Elem())
+				v.FieldByName("Signer").Set(s.Elem())
 				return err
 			case "Majority":
 				var majority Majority
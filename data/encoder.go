@@ -82,14 +82,11 @@ func writeRaw(w io.Writer, value interface{}, ignoreSigningFields bool) error {
 		return nil
 	case *InnerNode:
 		return write(w, v.Children)
-	case *Validation:
+	case *Validation, *Manifest:
 		return encode(w, value, ignoreSigningFields)
 	case *Proposal:
-		if ignoreSigningFields {
-			return writeValues(w, v.SigningValues())
-		} else {
-			return write(w, v)
-		}
+		// Proposals are only ever hashed for signing
+		return writeValues(w, v.SigningValues())
 	case *TransactionWithMetaData:
 		txid, tx, err := Raw(v.Transaction)
 		if err != nil {
@@ -126,10 +123,10 @@ func encode(w io.Writer, value interface{}, ignoreSigningFields bool) error {
 	v := reflect.Indirect(reflect.ValueOf(value))
 	fields := getFields(&v, 0)
 	// fmt.Println(fields.String())
+	if ignoreSigningFields {
+		fields = fields.withoutSigningFields()
+	}
 	return fields.Each(func(e enc, v interface{}) error {
-		if ignoreSigningFields && e.SigningField() {
-			return nil
-		}
 		if err := writeEncoding(w, e); err != nil {
 			return err
 		}
@@ -229,6 +226,20 @@ func (s fieldSlice) Each(f func(e enc, v interface{}) error) error {
 	return nil
 }
 
+// Signing fields are removed along with their children, ie. the Signer
+// objects of a Signers array
+func (s fieldSlice) withoutSigningFields() fieldSlice {
+	var fields fieldSlice
+	for _, field := range s {
+		if field.encoding.SigningField() {
+			continue
+		}
+		field.children = field.children.withoutSigningFields()
+		fields = append(fields, field)
+	}
+	return fields
+}
+
 func (f fieldSlice) String() string {
 	var s []string
 	f.Each(func(e enc, v interface{}) error {
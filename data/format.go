@@ -9,6 +9,7 @@ import (
 
 type NodeType uint8
 type NodeFormat uint8
+type WireType uint8
 type HashPrefix uint32
 type LedgerNamespace uint16
 
@@ -23,6 +24,7 @@ const (
 	HP_TRANSACTION_MULTISIGN HashPrefix = 0x534D5400 // 'SMT' inner transaction to multi-sign
 	HP_VALIDATION            HashPrefix = 0x56414C00 // 'VAL' validation for signing
 	HP_PROPOSAL              HashPrefix = 0x50525000 // 'PRP' proposal for signing
+	HP_MANIFEST              HashPrefix = 0x4D414E00 // 'MAN' manifest
 
 	// Node Types
 	NT_UNKNOWN          NodeType = 0
@@ -36,6 +38,13 @@ const (
 	NF_HASH   NodeFormat = 2
 	NF_WIRE   NodeFormat = 3
 
+	// Wire Types, which follow nodes received via the peer network
+	WT_TRANSACTION           WireType = 0
+	WT_ACCOUNT_STATE         WireType = 1
+	WT_INNER                 WireType = 2
+	WT_COMPRESSED_INNER      WireType = 3
+	WT_TRANSACTION_WITH_META WireType = 4
+
 	// Ledger index NameSpaces
 	NS_ACCOUNT         LedgerNamespace = 'a'
 	NS_DIRECTORY_NODE  LedgerNamespace = 'd'
@@ -371,7 +380,7 @@ func init() {
 	signingFields = make(map[enc]struct{})
 	for e, name := range encodings {
 		reverseEncodings[name] = e
-		if strings.Contains(name, "Signature") {
+		if strings.Contains(name, "Signature") || name == "Signers" {
 			signingFields[e] = struct{}{}
 		}
 	}
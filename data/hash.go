@@ -276,6 +276,65 @@ func NewSeedFromAddress(s string) (*Seed, error) {
 	return &seed, nil
 }
 
+// Expects the RFC1751 words of rippled's master_key, ie.
+// "I IRE BOND BOW TRIO LAID SEAT GOAL HEN IBIS IBIS DARE"
+func NewSeedFromRFC1751(s string) (*Seed, error) {
+	b, err := crypto.RFC1751Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	var seed Seed
+	if len(b) != len(seed) {
+		return nil, fmt.Errorf("rfc1751: expected 12 words not %d", len(b)*6/8)
+	}
+	// rippled encodes the seed in reverse
+	for i := range seed {
+		seed[i] = b[len(b)-1-i]
+	}
+	return &seed, nil
+}
+
+// Expects the 8 blocks of 6 digits of XLS-12 secret numbers
+func NewSeedFromSecretNumbers(s string) (*Seed, error) {
+	b, err := crypto.SecretNumbersDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	var seed Seed
+	copy(seed[:], b)
+	return &seed, nil
+}
+
+// ParseSeed accepts a seed as a base58 address, RFC1751 words or secret
+// numbers
+func ParseSeed(s string) (*Seed, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Trim(s, "0123456789- \t") == "":
+		return NewSeedFromSecretNumbers(s)
+	case len(strings.Fields(s)) == 1:
+		return NewSeedFromAddress(s)
+	default:
+		return NewSeedFromRFC1751(s)
+	}
+}
+
+// RFC1751 returns the seed as the words of rippled's master_key
+func (s Seed) RFC1751() string {
+	var reversed Seed
+	for i := range s {
+		reversed[i] = s[len(s)-1-i]
+	}
+	words, _ := crypto.RFC1751Encode(reversed[:])
+	return words
+}
+
+// SecretNumbers returns the seed as XLS-12 secret numbers
+func (s Seed) SecretNumbers() string {
+	numbers, _ := crypto.SecretNumbersEncode(s[:])
+	return numbers
+}
+
 func (s Seed) Hash() (crypto.Hash, error) {
 	return crypto.NewFamilySeed(s[:])
 }
@@ -39,3 +39,34 @@ func decodeHex(s string, t *testing.T) []byte {
 // 		t.Log(tx)
 // 	}
 // }
+
+func TestSeedFormats(t *testing.T) {
+	// The seed of "masterpassphrase" from rippled's wallet_propose
+	seed, err := NewSeedFromAddress("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := seed.RFC1751(); words != "I IRE BOND BOW TRIO LAID SEAT GOAL HEN IBIS IBIS DARE" {
+		t.Errorf("RFC1751: %s", words)
+	}
+	for _, s := range []string{
+		"snoPBrXtMeMyMHUVTgbuqAfg1SUTb",
+		"i ire bond bow trio laid seat goal hen ibis ibis dare",
+		seed.SecretNumbers(),
+	} {
+		parsed, err := ParseSeed(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+			continue
+		}
+		if *parsed != *seed {
+			t.Errorf("%s: got %s", s, parsed)
+		}
+		if account := parsed.AccountId(ECDSA, new(uint32)); account.String() != "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh" {
+			t.Errorf("%s: account %s", s, account)
+		}
+	}
+	if _, err := NewSeedFromRFC1751("TIDE ITCH SLOW REIN RULE MOT"); err == nil {
+		t.Errorf("expected 6 words to be refused")
+	}
+}
@@ -41,6 +41,12 @@ func LedgerIndex(le LedgerEntry) (*Hash256, error) {
 		return buildIndex([]interface{}{NS_FEE})
 	case *Amendments:
 		return buildIndex([]interface{}{NS_AMENDMENT})
+	case *Check:
+		return GetCheckIndex(*v.Account, *v.Sequence)
+	case *Ticket:
+		return GetTicketIndex(*v.Account, *v.TicketSequence)
+	case *AMM:
+		return GetAMMIndex(*v.Asset, *v.Asset2)
 	default:
 		return nil, fmt.Errorf("Unknown LedgerEntry")
 	}
@@ -83,6 +89,35 @@ func GetBookIndex(paysCurrency, getsCurrency Hash160, paysIssuer, getsIssuer Has
 	return index, nil
 }
 
+func GetEscrowIndex(account Account, sequence uint32) (*Hash256, error) {
+	return buildIndex([]interface{}{NS_SUSPAY, account.Bytes(), sequence})
+}
+
+func GetCheckIndex(account Account, sequence uint32) (*Hash256, error) {
+	return buildIndex([]interface{}{NS_CHECK, account.Bytes(), sequence})
+}
+
+func GetPaymentChannelIndex(account, destination Account, sequence uint32) (*Hash256, error) {
+	return buildIndex([]interface{}{NS_XRPU_CHANNEL, account.Bytes(), destination.Bytes(), sequence})
+}
+
+func GetTicketIndex(account Account, ticketSequence uint32) (*Hash256, error) {
+	return buildIndex([]interface{}{NS_TICKET, account.Bytes(), ticketSequence})
+}
+
+func GetSignerListIndex(account Account) (*Hash256, error) {
+	// rippled only ever uses a SignerListID of zero
+	return buildIndex([]interface{}{NS_SIGNER_LIST, account.Bytes(), uint32(0)})
+}
+
+// The two issues are ordered by currency and then issuer, as in rippled
+func GetAMMIndex(a, b Issue) (*Hash256, error) {
+	if b.Less(a) {
+		a, b = b, a
+	}
+	return buildIndex([]interface{}{NS_AMM, a.Issuer.Bytes(), a.Currency.Bytes(), b.Issuer.Bytes(), b.Currency.Bytes()})
+}
+
 func GetFeeIndex() (*Hash256, error) {
 	return buildIndex([]interface{}{NS_FEE})
 }
@@ -0,0 +1,55 @@
+package data
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type IndexSuite struct{}
+
+var _ = Suite(&IndexSuite{})
+
+func mustAccount(c *C, s string) Account {
+	account, err := NewAccountFromAddress(s)
+	c.Assert(err, IsNil)
+	return *account
+}
+
+func (s *IndexSuite) TestKeylets(c *C) {
+	tests := []struct {
+		description string
+		index       func() (*Hash256, error)
+		expected    string
+	}{
+		{"AccountRoot", func() (*Hash256, error) {
+			return GetAccountRootIndex(mustAccount(c, "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"))
+		}, "2B6AC232AA4C4BE41BF49D2459FA4A0347E1B543A4C92FCEE0821C0201E2E9A8"},
+		{"Offer", func() (*Hash256, error) {
+			return GetOfferIndex(mustAccount(c, "r32UufnaCGL82HubijgJGDmdE5hac7ZvLw"), 137)
+		}, "03F0AED09DEEE74CEF85CD57A0429D6113507CF759C597BABB4ADB752F734CE3"},
+		{"Escrow", func() (*Hash256, error) {
+			return GetEscrowIndex(mustAccount(c, "rDx69ebzbowuqztksVDmZXjizTd12BVr4x"), 84)
+		}, "61E8E8ED53FA2CEBE192B23897071E9A75217BF5A410E9CB5B45AAB7AECA567A"},
+		{"PayChannel", func() (*Hash256, error) {
+			a := mustAccount(c, "rDx69ebzbowuqztksVDmZXjizTd12BVr4x")
+			b := mustAccount(c, "rLFtVprxUEfsH54eCWKsZrEQzMDsx1wqso")
+			return GetPaymentChannelIndex(a, b, 82)
+		}, "E35708503B3C3143FB522D749AAFCC296E8060F0FB371A9A56FAE0B1ED127366"},
+	}
+	for _, test := range tests {
+		index, err := test.index()
+		c.Assert(err, IsNil, Commentf(test.description))
+		c.Check(index.String(), Equals, test.expected, Commentf(test.description))
+	}
+}
+
+func (s *IndexSuite) TestAMMIndexOrdering(c *C) {
+	usd, err := NewCurrency("USD")
+	c.Assert(err, IsNil)
+	xrp := Issue{}
+	iou := Issue{Currency: usd, Issuer: mustAccount(c, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")}
+	a, err := GetAMMIndex(xrp, iou)
+	c.Assert(err, IsNil)
+	b, err := GetAMMIndex(iou, xrp)
+	c.Assert(err, IsNil)
+	c.Assert(*a, Equals, *b)
+}
@@ -29,7 +29,7 @@ type MultiSignable interface {
 
 type Router interface {
 	Hashable
-	SuppressionId() Hash256
+	SuppressionId() (Hash256, error)
 }
 
 type Storer interface {
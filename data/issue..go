@@ -13,3 +13,22 @@ func (i Issue) String() string {
 	}
 	return fmt.Sprintf("%s/%s", i.Currency, i.Issuer)
 }
+
+func (i Issue) Less(other Issue) bool {
+	if c := i.Currency.Compare(other.Currency); c != 0 {
+		return c < 0
+	}
+	return i.Issuer.Less(other.Issuer)
+}
+
+func (i Issue) Asset() *Asset {
+	if i.Currency.IsNative() {
+		return &Asset{
+			Currency: "XRP",
+		}
+	}
+	return &Asset{
+		Currency: i.Currency.Machine(),
+		Issuer:   i.Issuer.String(),
+	}
+}
@@ -3,6 +3,8 @@ package data
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bits-and-blooms/bitset"
@@ -21,6 +23,64 @@ type LedgerRange struct {
 	Max   uint32
 }
 
+type LedgerRangeSlice []LedgerRange
+
+// NewLedgerRangeSlice parses rippled's complete_ledgers format,
+// for example "32570-6959228,6959230". "empty" returns no ranges.
+func NewLedgerRangeSlice(s string) (LedgerRangeSlice, error) {
+	var ranges LedgerRangeSlice
+	if s == "" || s == "empty" {
+		return ranges, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		start, err := strconv.ParseUint(bounds[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad ledger range: %s", part)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.ParseUint(bounds[1], 10, 32); err != nil || end < start {
+				return nil, fmt.Errorf("bad ledger range: %s", part)
+			}
+		}
+		ranges = append(ranges, LedgerRange{
+			Start: uint32(start),
+			End:   uint32(end),
+			Max:   uint32(end-start) + 1,
+		})
+	}
+	return ranges, nil
+}
+
+func (r *LedgerRange) Contains(sequence uint32) bool {
+	return sequence >= r.Start && sequence <= r.End
+}
+
+func (s LedgerRangeSlice) Contains(sequence uint32) bool {
+	for i := range s {
+		if s[i].Contains(sequence) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s LedgerRangeSlice) String() string {
+	if len(s) == 0 {
+		return "empty"
+	}
+	parts := make([]string, len(s))
+	for i, r := range s {
+		if r.Start == r.End {
+			parts[i] = fmt.Sprint(r.Start)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.Start, r.End)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
 type Work struct {
 	*LedgerRange
 	MissingLedgers LedgerSlice
@@ -104,6 +164,19 @@ func (l *LedgerSet) TakeMiddle(r *LedgerRange) LedgerSlice {
 	return ledgers
 }
 
+// TakeMiddleTop is TakeMiddle working down from the top of the range, so
+// that it returns the highest ledgers in the range, sorted
+func (l *LedgerSet) TakeMiddleTop(r *LedgerRange) LedgerSlice {
+	ledgers := make(LedgerSlice, 0, r.Max)
+	start, end := max(r.Start, l.start), min(r.End, uint32(l.ledgers.Len()))
+	for i := int64(end); i >= int64(start) && uint32(len(ledgers)) < r.Max; i-- {
+		if l.take(uint32(i)) {
+			ledgers = append(ledgers, uint32(i))
+		}
+	}
+	return ledgers.Sorted()
+}
+
 func (l *LedgerSet) TakeBottom(n uint32) LedgerSlice {
 	r := &LedgerRange{l.start, uint32(l.ledgers.Len()), n}
 	return l.TakeMiddle(r)
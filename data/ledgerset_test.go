@@ -35,6 +35,11 @@ func (s *LedgerSetSuite) TestLedgerSetMiddle(c *C) {
 	middle := l.TakeMiddle(r)
 	c.Assert(len(middle), Equals, 4)
 	c.Assert(middle, DeepEquals, LedgerSlice{32580, 32581, 32582, 32583})
+	l.Set(32619)
+	top := l.TakeMiddleTop(r)
+	c.Assert(top, DeepEquals, LedgerSlice{32616, 32617, 32618, 32620})
+	r.Start = 32616
+	c.Assert(l.TakeMiddleTop(r), HasLen, 0)
 	c.Assert(l.Max(), Equals, uint32(32670))
 	l.Extend(32690)
 	c.Assert(l.Max(), Equals, uint32(32690))
@@ -58,3 +63,18 @@ func (s *LedgerSetSuite) TestLedgerSetMiddle(c *C) {
 // 	}
 // 	fmt.Println(l.String())
 // }
+
+func (s *LedgerSetSuite) TestLedgerRangeSlice(c *C) {
+	ranges, err := NewLedgerRangeSlice("32570-6959228,6959230,6959232-6959240")
+	c.Assert(err, IsNil)
+	c.Assert(ranges, DeepEquals, LedgerRangeSlice{{32570, 6959228, 6926659}, {6959230, 6959230, 1}, {6959232, 6959240, 9}})
+	c.Assert(ranges.Contains(32570), Equals, true)
+	c.Assert(ranges.Contains(6959229), Equals, false)
+	c.Assert(ranges.Contains(6959230), Equals, true)
+	c.Assert(ranges.String(), Equals, "32570-6959228,6959230,6959232-6959240")
+	empty, err := NewLedgerRangeSlice("empty")
+	c.Assert(err, IsNil)
+	c.Assert(empty, HasLen, 0)
+	_, err = NewLedgerRangeSlice("10-5")
+	c.Assert(err, NotNil)
+}
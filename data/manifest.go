@@ -0,0 +1,99 @@
+package data
+
+import (
+	"fmt"
+
+	"github.com/rubblelabs/ripple/crypto"
+)
+
+// The Sequence of a manifest which revokes its master key
+const ManifestRevoked uint32 = 0xFFFFFFFF
+
+// Manifest delegates a validator's master key to an ephemeral key which
+// signs its validations. A later Sequence replaces an earlier manifest and a
+// revocation, which has no ephemeral key, retires the master key for good.
+type Manifest struct {
+	Hash            Hash256
+	PublicKey       PublicKey
+	SigningPubKey   *PublicKey
+	Sequence        uint32
+	Domain          *VariableLength
+	Signature       *VariableLength
+	MasterSignature VariableLength
+}
+
+func (m Manifest) GetType() string     { return "Manifest" }
+func (m *Manifest) Prefix() HashPrefix { return HP_MANIFEST }
+func (m *Manifest) GetHash() *Hash256  { return &m.Hash }
+func (m *Manifest) Revoked() bool      { return m.Sequence == ManifestRevoked }
+
+// SignManifest sets the manifest's keys and signs it with both. The
+// ephemeral signer is ignored for a revocation.
+func SignManifest(m *Manifest, master, ephemeral crypto.Signer) error {
+	public, err := master.PublicKey()
+	if err != nil {
+		return err
+	}
+	copy(m.PublicKey[:], public)
+	m.SigningPubKey, m.Signature = nil, nil
+	if !m.Revoked() {
+		if public, err = ephemeral.PublicKey(); err != nil {
+			return err
+		}
+		m.SigningPubKey = new(PublicKey)
+		copy(m.SigningPubKey[:], public)
+	}
+	hash, msg, err := raw(m, HP_MANIFEST, nil, true)
+	if err != nil {
+		return err
+	}
+	msg = append(HP_MANIFEST.Bytes(), msg...)
+	if !m.Revoked() {
+		sig, err := ephemeral.Sign(hash.Bytes(), msg)
+		if err != nil {
+			return err
+		}
+		signature := VariableLength(sig)
+		m.Signature = &signature
+	}
+	if m.MasterSignature, err = master.Sign(hash.Bytes(), msg); err != nil {
+		return err
+	}
+	m.Hash, _, err = Raw(m)
+	return err
+}
+
+// CheckManifest verifies the master signature and, unless the manifest is a
+// revocation, the ephemeral signature
+func CheckManifest(m *Manifest) error {
+	hash, msg, err := raw(m, HP_MANIFEST, nil, true)
+	if err != nil {
+		return err
+	}
+	msg = append(HP_MANIFEST.Bytes(), msg...)
+	if err := verifyManifest(m.PublicKey, hash, msg, m.MasterSignature); err != nil {
+		return fmt.Errorf("Manifest %d master signature: %s", m.Sequence, err)
+	}
+	if m.Revoked() {
+		return nil
+	}
+	if m.SigningPubKey == nil || m.Signature == nil {
+		return fmt.Errorf("Manifest %d has no ephemeral key", m.Sequence)
+	}
+	if err := verifyManifest(*m.SigningPubKey, hash, msg, *m.Signature); err != nil {
+		return fmt.Errorf("Manifest %d ephemeral signature: %s", m.Sequence, err)
+	}
+	return nil
+}
+
+func verifyManifest(public PublicKey, hash Hash256, msg []byte, signature VariableLength) error {
+	ok, err := crypto.Verify(public.Bytes(), hash.Bytes(), msg, signature.Bytes())
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return fmt.Errorf("invalid")
+	default:
+		return nil
+	}
+}
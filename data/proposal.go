@@ -1,5 +1,16 @@
 package data
 
+import (
+	"bytes"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+)
+
+// ProposalBowOut is the Sequence of a proposal from a validator leaving
+// the consensus round
+const ProposalBowOut uint32 = 0xFFFFFFFF
+
 type Proposal struct {
 	Hash           Hash256
 	LedgerHash     Hash256
@@ -10,6 +21,17 @@ type Proposal struct {
 	Signature      VariableLength
 }
 
+// NewProposal returns a validator's first position, the hash of the
+// transaction set it proposes, in the round building on previousLedger.
+// Later positions in the same round increment the Sequence.
+func NewProposal(previousLedger, position Hash256, closeTime time.Time) *Proposal {
+	return &Proposal{
+		LedgerHash:     position,
+		PreviousLedger: previousLedger,
+		CloseTime:      *NewRippleTimeFromTime(closeTime),
+	}
+}
+
 func (p Proposal) GetType() string                { return "Proposal" }
 func (p *Proposal) GetPublicKey() *PublicKey      { return &p.PublicKey }
 func (p *Proposal) GetSignature() *VariableLength { return &p.Signature }
@@ -27,13 +49,25 @@ func (p Proposal) SigningValues() []interface{} {
 	}
 }
 
+// SuppressionId is the hash of the proposal as relayed between peers
 func (p Proposal) SuppressionId() (Hash256, error) {
-	return hashValues([]interface{}{
+	var id Hash256
+	var b bytes.Buffer
+	values := []interface{}{
 		p.LedgerHash,
 		p.PreviousLedger,
 		p.Sequence,
 		p.CloseTime.Uint32(),
-		p.PublicKey,
-		p.Signature,
-	})
+	}
+	if err := writeValues(&b, values); err != nil {
+		return id, err
+	}
+	if err := writeVariableLength(&b, p.PublicKey.Bytes()); err != nil {
+		return id, err
+	}
+	if err := writeVariableLength(&b, p.Signature.Bytes()); err != nil {
+		return id, err
+	}
+	copy(id[:], crypto.Sha512Half(b.Bytes()))
+	return id, nil
 }
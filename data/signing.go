@@ -7,13 +7,22 @@ import (
 )
 
 func Sign(s Signable, key crypto.Key, sequence *uint32) error {
+	return SignWith(s, crypto.NewKeySigner(key, sequence))
+}
+
+// SignWith signs using a Signer which may hold its key outside this process
+func SignWith(s Signable, signer crypto.Signer) error {
+	public, err := signer.PublicKey()
+	if err != nil {
+		return err
+	}
 	s.InitialiseForSigning()
-	copy(s.GetPublicKey().Bytes(), key.Public(sequence))
+	copy(s.GetPublicKey().Bytes(), public)
 	hash, msg, err := SigningHash(s)
 	if err != nil {
 		return err
 	}
-	sig, err := crypto.Sign(key.Private(sequence), hash.Bytes(), append(s.SigningPrefix().Bytes(), msg...))
+	sig, err := signer.Sign(hash.Bytes(), append(s.SigningPrefix().Bytes(), msg...))
 	if err != nil {
 		return err
 	}
@@ -31,10 +40,17 @@ func CheckSignature(s Signable) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	msg = append(s.SigningPrefix().Bytes(), msg...)
 	return crypto.Verify(s.GetPublicKey().Bytes(), hash.Bytes(), msg, s.GetSignature().Bytes())
 }
 
 func MultiSign(s MultiSignable, key crypto.Key, sequence *uint32, account Account) error {
+	return MultiSignWith(s, crypto.NewKeySigner(key, sequence), account)
+}
+
+// MultiSignWith signs for account using a Signer which may hold its key
+// outside this process
+func MultiSignWith(s MultiSignable, signer crypto.Signer, account Account) error {
 	s.InitialiseForSigning()
 	hash, msg, err := MultiSigningHash(s, account)
 	if err != nil {
@@ -44,13 +60,17 @@ func MultiSign(s MultiSignable, key crypto.Key, sequence *uint32, account Accoun
 	msg = append(s.MultiSigningPrefix().Bytes(), msg...)
 	msg = append(msg, account.Bytes()...)
 
-	sig, err := crypto.Sign(key.Private(sequence), hash.Bytes(), msg)
+	sig, err := signer.Sign(hash.Bytes(), msg)
+	if err != nil {
+		return err
+	}
+	public, err := signer.PublicKey()
 	if err != nil {
 		return err
 	}
 	*s.GetSignature() = sig
 	// copy pub key only after the signing
-	copy(s.GetPublicKey().Bytes(), key.Public(sequence))
+	copy(s.GetPublicKey().Bytes(), public)
 
 	return nil
 }
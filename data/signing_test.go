@@ -0,0 +1,47 @@
+package data
+
+import (
+	"github.com/rubblelabs/ripple/crypto"
+	. "gopkg.in/check.v1"
+)
+
+type SigningSuite struct{}
+
+var _ = Suite(&SigningSuite{})
+
+// Ed25519 signs the whole prefixed signing data, whereas ECDSA signs its
+// hash, so a missing prefix only shows up with Ed25519 keys
+func (s *SigningSuite) TestCheckSignature(c *C) {
+	seed, err := NewSeedFromAddress("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	c.Assert(err, IsNil)
+	ed25519, err := crypto.NewEd25519Key(seed[:])
+	c.Assert(err, IsNil)
+	ecdsa, err := crypto.NewECDSAKey(seed[:])
+	c.Assert(err, IsNil)
+	destination, err := NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	amount, err := NewAmount("1000000")
+	c.Assert(err, IsNil)
+	for _, key := range []struct {
+		key      crypto.Key
+		sequence *uint32
+	}{{ed25519, nil}, {ecdsa, new(uint32)}} {
+		tx := &Payment{
+			TxBase: TxBase{
+				TransactionType: PAYMENT,
+				Sequence:        1,
+			},
+			Destination: *destination,
+			Amount:      *amount,
+		}
+		copy(tx.Account[:], key.key.Id(key.sequence))
+		c.Assert(Sign(tx, key.key, key.sequence), IsNil)
+		ok, err := CheckSignature(tx)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+
+		tx.Sequence++
+		ok, _ = CheckSignature(tx)
+		c.Assert(ok, Equals, false)
+	}
+}
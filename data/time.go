@@ -22,6 +22,10 @@ func NewRippleTime(t uint32) *RippleTime {
 	return &RippleTime{t}
 }
 
+func NewRippleTimeFromTime(t time.Time) *RippleTime {
+	return &RippleTime{convertToRippleTime(t)}
+}
+
 func convertToRippleTime(t time.Time) uint32 {
 	return uint32(t.Sub(time.Unix(rippleTimeEpoch, 0)).Nanoseconds() / 1000000000)
 }
@@ -1,5 +1,7 @@
 package data
 
+import "reflect"
+
 type TxBase struct {
 	TransactionType    TransactionType
 	Flags              *TransactionFlag `json:",omitempty"`
@@ -352,6 +354,28 @@ func (t *TxBase) InitialiseForSigning() {
 	}
 }
 
+// TicketSequence returns the ticket a transaction uses in place of its
+// Sequence, or nil if it uses its Sequence or its type cannot use tickets.
+func TicketSequence(tx Transaction) *uint32 {
+	v := reflect.Indirect(reflect.ValueOf(tx)).FieldByName("TicketSequence")
+	if !v.IsValid() || v.IsNil() {
+		return nil
+	}
+	return v.Interface().(*uint32)
+}
+
+// SetTicketSequence uses ticket in place of the transaction's Sequence,
+// returning false if its type cannot use tickets.
+func SetTicketSequence(tx Transaction, ticket uint32) bool {
+	v := reflect.Indirect(reflect.ValueOf(tx)).FieldByName("TicketSequence")
+	if !v.IsValid() {
+		return false
+	}
+	v.Set(reflect.ValueOf(&ticket))
+	tx.GetBase().Sequence = 0
+	return true
+}
+
 func (o *OfferCreate) Ratio() *Value {
 	return o.TakerPays.Ratio(o.TakerGets)
 }
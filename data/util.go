@@ -1,9 +1,5 @@
 package data
 
-import (
-	"crypto/sha512"
-)
-
 const hextable = "0123456789ABCDEF"
 
 //faster than fmt and need upper case!
@@ -50,15 +46,3 @@ func abs(a int64) uint64 {
 	}
 	return uint64(a)
 }
-
-func hashValues(values []interface{}) (Hash256, error) {
-	var hash Hash256
-	hasher := sha512.New()
-	for _, v := range values {
-		if err := write(hasher, v); err != nil {
-			return hash, err
-		}
-	}
-	copy(hash[:], hasher.Sum(nil))
-	return hash, nil
-}
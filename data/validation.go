@@ -1,5 +1,17 @@
 package data
 
+import (
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+)
+
+// Set in Validation.Flags
+const (
+	ValidationFull               uint32 = 0x00000001
+	ValidationCanonicalSignature uint32 = 0x80000000
+)
+
 type Validation struct {
 	Hash             Hash256
 	Flags            uint32
@@ -16,11 +28,50 @@ type Validation struct {
 	ReserveIncrement *uint32
 }
 
-func (v Validation) GetType() string                 { return "Validation" }
-func (v Validation) GetPublicKey() *PublicKey        { return &v.SigningPubKey }
-func (v Validation) GetSignature() *VariableLength   { return &v.Signature }
-func (v Validation) Prefix() HashPrefix              { return HP_VALIDATION }
-func (v Validation) SigningPrefix() HashPrefix       { return HP_VALIDATION }
-func (v Validation) SuppressionId() (Hash256, error) { return NodeId(&v) }
-func (v Validation) GetHash() *Hash256               { return &v.Hash }
-func (v Validation) InitialiseForSigning()           {}
+// NewValidation returns a full validation of a ledger, ready to be signed
+// with Sign or SignWith
+func NewValidation(ledgerHash Hash256, ledgerSequence uint32, signingTime time.Time) *Validation {
+	return &Validation{
+		Flags:          ValidationFull | ValidationCanonicalSignature,
+		LedgerHash:     ledgerHash,
+		LedgerSequence: ledgerSequence,
+		SigningTime:    *NewRippleTimeFromTime(signingTime),
+	}
+}
+
+func (v Validation) GetType() string                { return "Validation" }
+func (v *Validation) GetPublicKey() *PublicKey      { return &v.SigningPubKey }
+func (v *Validation) GetSignature() *VariableLength { return &v.Signature }
+func (v Validation) Prefix() HashPrefix             { return HP_VALIDATION }
+func (v Validation) SigningPrefix() HashPrefix      { return HP_VALIDATION }
+func (v *Validation) GetHash() *Hash256             { return &v.Hash }
+func (v Validation) InitialiseForSigning()          {}
+
+// SuppressionId is the hash of the validation as relayed between peers
+func (v *Validation) SuppressionId() (Hash256, error) {
+	var id Hash256
+	_, raw, err := Raw(v)
+	if err != nil {
+		return id, err
+	}
+	copy(id[:], crypto.Sha512Half(raw))
+	return id, nil
+}
+
+// Full is false for a partial validation, which does not count towards
+// quorum
+func (v Validation) Full() bool { return v.Flags&ValidationFull != 0 }
+
+// SetPartial marks a validation made by a validator which is not in sync
+// with the network
+func (v *Validation) SetPartial() { v.Flags &^= ValidationFull }
+
+// SetLoadFee sets the fee level, relative to 256, charged for
+// transactions by the validator
+func (v *Validation) SetLoadFee(fee uint32) { v.LoadFee = &fee }
+
+// SetFeeVote sets the fees the validator votes for, which are sent with
+// validations of the ledger before a flag ledger
+func (v *Validation) SetFeeVote(baseFee uint64, reserveBase, reserveIncrement uint32) {
+	v.BaseFee, v.ReserveBase, v.ReserveIncrement = &baseFee, &reserveBase, &reserveIncrement
+}
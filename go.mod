@@ -10,6 +10,7 @@ require (
 	github.com/gorilla/websocket v1.4.2
 	github.com/juju/testing v0.0.0-20210324180055-18c50b0c2098
 	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	golang.org/x/term v0.0.0-20220411215600-e5f449aeb171
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
 )
 
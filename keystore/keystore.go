@@ -0,0 +1,344 @@
+// Package keystore keeps labelled seeds in a file encrypted with a password,
+// so that they need not appear on command lines or in configuration.
+//
+// The encryption key is derived from the password with scrypt and each seed
+// is sealed separately with AES-GCM. A seed's label, key type and account are
+// authenticated along with it, so entries cannot be relabelled or swapped
+// without the password.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	"golang.org/x/crypto/scrypt"
+)
+
+const version = 1
+
+// Authenticated with an empty plaintext so the password can be checked even
+// when there are no seeds
+var checkData = []byte("ripple keystore")
+
+// Can be lowered by tests
+var scryptN = 1 << 15
+
+type kdf struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// Entry describes a seed without revealing it
+type Entry struct {
+	Label   string       `json:"label"`
+	KeyType data.KeyType `json:"-"`
+	Account data.Account `json:"account"`
+	Created time.Time    `json:"created"`
+}
+
+type entry struct {
+	Entry
+	Type string `json:"key_type"`
+	Seed string `json:"seed"`
+}
+
+type file struct {
+	Version int      `json:"version"`
+	KDF     kdf      `json:"kdf"`
+	Check   string   `json:"check"`
+	Keys    []*entry `json:"keys"`
+}
+
+// Keystore is an unlocked keystore
+type Keystore struct {
+	file
+	aead cipher.AEAD
+}
+
+// New creates an empty keystore protected by password
+func New(password []byte) (*Keystore, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	k := &Keystore{
+		file: file{
+			Version: version,
+			KDF: kdf{
+				Name: "scrypt",
+				N:    scryptN,
+				R:    8,
+				P:    1,
+				Salt: fmt.Sprintf("%x", salt),
+			},
+		},
+	}
+	if err := k.unlock(password); err != nil {
+		return nil, err
+	}
+	check, err := k.seal(nil, checkData)
+	if err != nil {
+		return nil, err
+	}
+	k.Check = check
+	return k, nil
+}
+
+// Read decodes and unlocks a keystore
+func Read(r io.Reader, password []byte) (*Keystore, error) {
+	var k Keystore
+	if err := json.NewDecoder(r).Decode(&k.file); err != nil {
+		return nil, err
+	}
+	if k.Version != version {
+		return nil, fmt.Errorf("keystore: unsupported version: %d", k.Version)
+	}
+	if k.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("keystore: unsupported kdf: %s", k.KDF.Name)
+	}
+	if err := k.unlock(password); err != nil {
+		return nil, err
+	}
+	if _, err := k.open(k.Check, checkData); err != nil {
+		return nil, fmt.Errorf("keystore: wrong password")
+	}
+	for _, e := range k.Keys {
+		keyType, err := ParseKeyType(e.Type)
+		if err != nil {
+			return nil, fmt.Errorf("keystore: %s: %s", e.Label, err)
+		}
+		e.KeyType = keyType
+	}
+	return &k, nil
+}
+
+// Open reads and unlocks the keystore at path
+func Open(path string, password []byte) (*Keystore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, password)
+}
+
+func (k *Keystore) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(&k.file)
+}
+
+// Save replaces the file at path, which is only readable by its owner
+func (k *Keystore) Save(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".keystore")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := k.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// List returns the entries sorted by label
+func (k *Keystore) List() []Entry {
+	entries := make([]Entry, len(k.Keys))
+	for i, e := range k.Keys {
+		entries[i] = e.Entry
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Label < entries[j].Label })
+	return entries
+}
+
+// Add encrypts a seed under a new label
+func (k *Keystore) Add(label string, seed data.Seed, keyType data.KeyType) (*Entry, error) {
+	if label == "" {
+		return nil, fmt.Errorf("keystore: missing label")
+	}
+	if k.find(label) != nil {
+		return nil, fmt.Errorf("keystore: %s already exists", label)
+	}
+	typ, err := formatKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	e := &entry{
+		Entry: Entry{
+			Label:   label,
+			KeyType: keyType,
+			Account: seed.AccountId(keyType, crypto.AccountSequence(seed.Key(keyType))),
+			Created: time.Now().UTC().Truncate(time.Second),
+		},
+		Type: typ,
+	}
+	sealed, err := k.seal(seed[:], e.additionalData())
+	if err != nil {
+		return nil, err
+	}
+	e.Seed = sealed
+	k.Keys = append(k.Keys, e)
+	return &e.Entry, nil
+}
+
+// Remove deletes the seed with the label
+func (k *Keystore) Remove(label string) error {
+	for i, e := range k.Keys {
+		if e.Label == label {
+			k.Keys = append(k.Keys[:i], k.Keys[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("keystore: unknown key: %s", label)
+}
+
+// Export decrypts the seed with the label
+func (k *Keystore) Export(label string) (*data.Seed, data.KeyType, error) {
+	e := k.find(label)
+	if e == nil {
+		return nil, 0, fmt.Errorf("keystore: unknown key: %s", label)
+	}
+	b, err := k.open(e.Seed, e.additionalData())
+	if err != nil {
+		return nil, 0, fmt.Errorf("keystore: %s: %s", label, err)
+	}
+	var seed data.Seed
+	if len(b) != len(seed) {
+		return nil, 0, fmt.Errorf("keystore: %s: bad seed length: %d", label, len(b))
+	}
+	copy(seed[:], b)
+	return &seed, e.KeyType, nil
+}
+
+// Signer returns a crypto.Signer for the account key of the seed with the
+// label
+func (k *Keystore) Signer(label string) (crypto.Signer, error) {
+	seed, keyType, err := k.Export(label)
+	if err != nil {
+		return nil, err
+	}
+	key := seed.Key(keyType)
+	return crypto.NewKeySigner(key, crypto.AccountSequence(key)), nil
+}
+
+// RotatePassword re-encrypts every seed with a key derived from the new
+// password and a fresh salt
+func (k *Keystore) RotatePassword(password []byte) error {
+	seeds := make([]*data.Seed, len(k.Keys))
+	for i, e := range k.Keys {
+		seed, _, err := k.Export(e.Label)
+		if err != nil {
+			return err
+		}
+		seeds[i] = seed
+	}
+	rotated, err := New(password)
+	if err != nil {
+		return err
+	}
+	for i, e := range k.Keys {
+		sealed, err := rotated.seal(seeds[i][:], e.additionalData())
+		if err != nil {
+			return err
+		}
+		rotated.Keys = append(rotated.Keys, &entry{
+			Entry: e.Entry,
+			Type:  e.Type,
+			Seed:  sealed,
+		})
+	}
+	*k = *rotated
+	return nil
+}
+
+func (k *Keystore) find(label string) *entry {
+	for _, e := range k.Keys {
+		if e.Label == label {
+			return e
+		}
+	}
+	return nil
+}
+
+func (k *Keystore) unlock(password []byte) error {
+	salt, err := hex.DecodeString(k.KDF.Salt)
+	if err != nil {
+		return fmt.Errorf("keystore: bad salt: %s", err)
+	}
+	key, err := scrypt.Key(password, salt, k.KDF.N, k.KDF.R, k.KDF.P, 32)
+	if err != nil {
+		return fmt.Errorf("keystore: %s", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	k.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// Returns the nonce followed by the ciphertext in hex
+func (k *Keystore) seal(plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", k.aead.Seal(nonce, nonce, plaintext, additionalData)), nil
+}
+
+func (k *Keystore) open(sealed string, additionalData []byte) ([]byte, error) {
+	b, err := hex.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < k.aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	nonce, ciphertext := b[:k.aead.NonceSize()], b[k.aead.NonceSize():]
+	return k.aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func (e *entry) additionalData() []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%s", e.Label, e.Type, e.Account))
+}
+
+func formatKeyType(keyType data.KeyType) (string, error) {
+	switch keyType {
+	case data.ECDSA:
+		return "secp256k1", nil
+	case data.Ed25519:
+		return "ed25519", nil
+	default:
+		return "", fmt.Errorf("keystore: unknown key type: %d", keyType)
+	}
+}
+
+// ParseKeyType accepts "secp256k1" or "ed25519"
+func ParseKeyType(s string) (data.KeyType, error) {
+	switch s {
+	case "secp256k1":
+		return data.ECDSA, nil
+	case "ed25519":
+		return data.Ed25519, nil
+	default:
+		return 0, fmt.Errorf("unknown key type: %s", s)
+	}
+}
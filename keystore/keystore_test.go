@@ -0,0 +1,104 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type KeystoreSuite struct{}
+
+var _ = Suite(&KeystoreSuite{})
+
+func (s *KeystoreSuite) SetUpSuite(c *C) {
+	scryptN = 1 << 10
+}
+
+func testSeed(c *C, password string) data.Seed {
+	hash, err := crypto.GenerateFamilySeed(password)
+	c.Assert(err, IsNil)
+	var seed data.Seed
+	copy(seed[:], hash.Payload())
+	return seed
+}
+
+func (s *KeystoreSuite) TestRoundTrip(c *C) {
+	hot, cold := testSeed(c, "hot"), testSeed(c, "cold")
+	k, err := New([]byte("first"))
+	c.Assert(err, IsNil)
+	_, err = k.Add("payouts", hot, data.ECDSA)
+	c.Assert(err, IsNil)
+	entry, err := k.Add("cold", cold, data.Ed25519)
+	c.Assert(err, IsNil)
+	c.Assert(entry.Account, Equals, cold.AccountId(data.Ed25519, nil))
+	_, err = k.Add("payouts", cold, data.ECDSA)
+	c.Assert(err, ErrorMatches, "keystore: payouts already exists")
+
+	path := filepath.Join(c.MkDir(), "keys.json")
+	c.Assert(k.Save(path), IsNil)
+	_, err = Open(path, []byte("wrong"))
+	c.Assert(err, ErrorMatches, "keystore: wrong password")
+	k, err = Open(path, []byte("first"))
+	c.Assert(err, IsNil)
+
+	list := k.List()
+	c.Assert(list, HasLen, 2)
+	c.Assert(list[0].Label, Equals, "cold")
+	c.Assert(list[0].KeyType, Equals, data.Ed25519)
+	c.Assert(list[1].Account, Equals, hot.AccountId(data.ECDSA, new(uint32)))
+	seed, keyType, err := k.Export("payouts")
+	c.Assert(err, IsNil)
+	c.Assert(*seed, Equals, hot)
+	c.Assert(keyType, Equals, data.ECDSA)
+	signer, err := k.Signer("cold")
+	c.Assert(err, IsNil)
+	id, err := crypto.SignerId(signer)
+	c.Assert(err, IsNil)
+	c.Assert(id, DeepEquals, list[0].Account.Bytes())
+
+	c.Assert(k.RotatePassword([]byte("second")), IsNil)
+	c.Assert(k.Save(path), IsNil)
+	_, err = Open(path, []byte("first"))
+	c.Assert(err, ErrorMatches, "keystore: wrong password")
+	k, err = Open(path, []byte("second"))
+	c.Assert(err, IsNil)
+	seed, _, err = k.Export("cold")
+	c.Assert(err, IsNil)
+	c.Assert(*seed, Equals, cold)
+	c.Assert(k.List()[0].Created.Equal(list[0].Created), Equals, true)
+}
+
+func (s *KeystoreSuite) TestTampered(c *C) {
+	k, err := New([]byte("password"))
+	c.Assert(err, IsNil)
+	_, err = k.Add("a", testSeed(c, "a"), data.ECDSA)
+	c.Assert(err, IsNil)
+	_, err = k.Add("b", testSeed(c, "b"), data.ECDSA)
+	c.Assert(err, IsNil)
+	var buf bytes.Buffer
+	c.Assert(k.Write(&buf), IsNil)
+
+	// Swap the labels so that "a" would sign with b's seed
+	var raw map[string]interface{}
+	c.Assert(json.Unmarshal(buf.Bytes(), &raw), IsNil)
+	keys := raw["keys"].([]interface{})
+	a, b := keys[0].(map[string]interface{}), keys[1].(map[string]interface{})
+	a["label"], b["label"] = b["label"], a["label"]
+	a["account"], b["account"] = b["account"], a["account"]
+	tampered, err := json.Marshal(raw)
+	c.Assert(err, IsNil)
+
+	k, err = Read(bytes.NewReader(tampered), []byte("password"))
+	c.Assert(err, IsNil)
+	_, _, err = k.Export("a")
+	c.Assert(err, ErrorMatches, "keystore: a: .*authentication failed")
+	c.Assert(k.Remove("a"), IsNil)
+	c.Assert(k.Remove("a"), ErrorMatches, "keystore: unknown key: a")
+}
@@ -0,0 +1,48 @@
+package keystore
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"golang.org/x/term"
+)
+
+// PasswordEnv is the environment variable read by Password, for scripts
+const PasswordEnv = "RIPPLE_KEYSTORE_PASSWORD"
+
+// Password returns the password in PasswordEnv if it is set, otherwise it
+// prompts for one on the terminal
+func Password(prompt string) ([]byte, error) {
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		return []byte(password), nil
+	}
+	password, err := ReadSecret(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("keystore: set %s when there is no terminal: %s", PasswordEnv, err)
+	}
+	return password, nil
+}
+
+// ReadSecret prompts for a line typed on the controlling terminal, which
+// is not echoed. Stdin may be redirected.
+func ReadSecret(prompt string) ([]byte, error) {
+	input, output := "/dev/tty", "/dev/tty"
+	if runtime.GOOS == "windows" {
+		input, output = "CONIN$", "CONOUT$"
+	}
+	in, err := os.OpenFile(input, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(output, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	fmt.Fprint(out, prompt)
+	secret, err := term.ReadPassword(int(in.Fd()))
+	fmt.Fprintln(out)
+	return secret, err
+}
@@ -0,0 +1,309 @@
+// Package multisign coordinates signing a transaction by the members of a
+// SignerList who are not online together. An unsigned transaction is
+// exported, each signer produces a partial signature from it offline and
+// the partials are then verified and combined into a submittable blob.
+package multisign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// Unsigned is a transaction awaiting signatures. TxBlob is authoritative,
+// TxJson is only there for signers to review.
+type Unsigned struct {
+	Hash   data.Hash256     `json:"hash"`
+	TxBlob string           `json:"tx_blob"`
+	TxJson data.Transaction `json:"tx_json"`
+}
+
+// Partial is one signer's signature for an Unsigned transaction
+type Partial struct {
+	Hash   data.Hash256    `json:"hash"`
+	Signer data.SignerItem `json:"Signer"`
+}
+
+// Export strips any signatures from the transaction and encodes it for
+// signing. The Fee must already allow for the signers, which cost one base
+// fee each on top of the usual base fee.
+func Export(tx data.Transaction) (*Unsigned, error) {
+	base := tx.GetBase()
+	switch {
+	case base.Account.IsZero():
+		return nil, fmt.Errorf("multisign: missing Account")
+	case base.Fee.IsZero():
+		return nil, fmt.Errorf("multisign: missing Fee")
+	case base.Sequence == 0 && data.TicketSequence(tx) == nil:
+		return nil, fmt.Errorf("multisign: missing Sequence")
+	}
+	base.SigningPubKey = new(data.PublicKey)
+	base.TxnSignature = nil
+	base.Signers = nil
+	hash, raw, err := data.Raw(tx)
+	if err != nil {
+		return nil, err
+	}
+	base.Hash = hash
+	return &Unsigned{
+		Hash:   hash,
+		TxBlob: fmt.Sprintf("%X", raw),
+		TxJson: tx,
+	}, nil
+}
+
+// Transaction decodes a fresh copy of the unsigned transaction from TxBlob
+func (u *Unsigned) Transaction() (data.Transaction, error) {
+	b, err := hex.DecodeString(u.TxBlob)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := data.ReadTransaction(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	hash, _, err := data.Raw(tx)
+	if err != nil {
+		return nil, err
+	}
+	if hash != u.Hash {
+		return nil, fmt.Errorf("multisign: tx_blob does not match hash %s", u.Hash)
+	}
+	*tx.GetHash() = hash
+	return tx, nil
+}
+
+// Sign produces account's signature for the transaction. The key may be
+// either the account's master key or its regular key.
+func (u *Unsigned) Sign(key crypto.Key, keySequence *uint32, account data.Account) (*Partial, error) {
+	return u.SignWith(crypto.NewKeySigner(key, keySequence), account)
+}
+
+// SignWith is Sign for keys held by a Signer
+func (u *Unsigned) SignWith(signer crypto.Signer, account data.Account) (*Partial, error) {
+	tx, err := u.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	if err := data.MultiSignWith(tx.(data.MultiSignable), signer, account); err != nil {
+		return nil, err
+	}
+	base := tx.GetBase()
+	return &Partial{
+		Hash: u.Hash,
+		Signer: data.SignerItem{
+			Account:       account,
+			SigningPubKey: base.SigningPubKey,
+			TxnSignature:  base.TxnSignature,
+		},
+	}, nil
+}
+
+// Signers is an account's SignerList along with the regular keys and
+// AccountRoot flags of the signers' accounts
+type Signers struct {
+	Account      data.Account
+	SignerQuorum uint32
+	Weights      map[data.Account]uint16
+	RegularKeys  map[data.Account]data.RegularKey
+	// Signers with LsDisableMaster can only sign with their regular key
+	Flags map[data.Account]data.LedgerEntryFlag
+}
+
+func NewSigners(account data.Account, list *data.SignerList) (*Signers, error) {
+	if list.SignerQuorum == nil {
+		return nil, fmt.Errorf("multisign: %s SignerList has no SignerQuorum", account)
+	}
+	s := &Signers{
+		Account:      account,
+		SignerQuorum: *list.SignerQuorum,
+		Weights:      make(map[data.Account]uint16),
+		RegularKeys:  make(map[data.Account]data.RegularKey),
+		Flags:        make(map[data.Account]data.LedgerEntryFlag),
+	}
+	for _, entry := range list.SignerEntries {
+		if entry.SignerEntry.Account == nil || entry.SignerEntry.SignerWeight == nil {
+			return nil, fmt.Errorf("multisign: %s SignerList has an incomplete entry", account)
+		}
+		s.Weights[*entry.SignerEntry.Account] = *entry.SignerEntry.SignerWeight
+	}
+	return s, nil
+}
+
+// FetchSigners looks up the account's SignerList and the regular key and
+// flags of each signer. Signers whose accounts do not exist can only sign with
+// their master key.
+func FetchSigners(client websockets.Client, account data.Account) (*Signers, error) {
+	objects, err := client.AccountObjects(account, "signer_list", "validated")
+	if err != nil {
+		return nil, err
+	}
+	var list *data.SignerList
+	for _, le := range objects.AccountObjects {
+		if l, ok := le.(*data.SignerList); ok {
+			list = l
+		}
+	}
+	if list == nil {
+		return nil, fmt.Errorf("multisign: %s has no SignerList", account)
+	}
+	s, err := NewSigners(account, list)
+	if err != nil {
+		return nil, err
+	}
+	for signer := range s.Weights {
+		info, err := client.AccountInfo(signer)
+		if cmdErr, ok := err.(*websockets.CommandError); ok && cmdErr.Name == "actNotFound" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if info.AccountData.RegularKey != nil {
+			s.RegularKeys[signer] = *info.AccountData.RegularKey
+		}
+		if info.AccountData.Flags != nil {
+			s.Flags[signer] = *info.AccountData.Flags
+		}
+	}
+	return s, nil
+}
+
+// Verify checks that the partial signature is valid for the transaction
+// and was made by a key which the signer is allowed to use
+func (s *Signers) Verify(tx data.Transaction, partial *Partial) error {
+	signer := partial.Signer
+	if _, ok := s.Weights[signer.Account]; !ok {
+		return fmt.Errorf("multisign: %s is not in the SignerList of %s", signer.Account, s.Account)
+	}
+	if signer.SigningPubKey == nil || signer.TxnSignature == nil {
+		return fmt.Errorf("multisign: %s partial is not signed", signer.Account)
+	}
+	var keyAccount data.Account
+	copy(keyAccount[:], crypto.Sha256RipeMD160(signer.SigningPubKey.Bytes()))
+	regularKey, hasRegularKey := s.RegularKeys[signer.Account]
+	switch {
+	case keyAccount == signer.Account:
+		if s.Flags[signer.Account]&data.LsDisableMaster != 0 {
+			return fmt.Errorf("multisign: %s signed with its master key, which is disabled", signer.Account)
+		}
+	case !hasRegularKey || data.Account(regularKey) != keyAccount:
+		return fmt.Errorf("multisign: %s signed with a key which is not theirs: %s", signer.Account, keyAccount)
+	}
+	multi := tx.(data.MultiSignable)
+	hash, msg, err := data.MultiSigningHash(multi, signer.Account)
+	if err != nil {
+		return err
+	}
+	msg = append(multi.MultiSigningPrefix().Bytes(), msg...)
+	msg = append(msg, signer.Account.Bytes()...)
+	ok, err := crypto.Verify(signer.SigningPubKey.Bytes(), hash.Bytes(), msg, signer.TxnSignature.Bytes())
+	if err != nil {
+		return fmt.Errorf("multisign: %s: %s", signer.Account, err)
+	}
+	if !ok {
+		return fmt.Errorf("multisign: %s has a bad signature", signer.Account)
+	}
+	return nil
+}
+
+// Combine verifies each partial signature and the quorum they reach before
+// adding them to the transaction, which is then ready to submit
+func (s *Signers) Combine(u *Unsigned, partials ...*Partial) (data.Transaction, error) {
+	tx, err := u.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	if account := tx.GetBase().Account; account != s.Account {
+		return nil, fmt.Errorf("multisign: transaction is for %s not %s", account, s.Account)
+	}
+	var (
+		weight  uint32
+		signers []data.Signer
+		seen    = make(map[data.Account]bool)
+	)
+	for _, partial := range partials {
+		if partial.Hash != u.Hash {
+			return nil, fmt.Errorf("multisign: %s signed a different transaction: %s", partial.Signer.Account, partial.Hash)
+		}
+		if seen[partial.Signer.Account] {
+			return nil, fmt.Errorf("multisign: %s signed more than once", partial.Signer.Account)
+		}
+		if err := s.Verify(tx, partial); err != nil {
+			return nil, err
+		}
+		seen[partial.Signer.Account] = true
+		weight += uint32(s.Weights[partial.Signer.Account])
+		signers = append(signers, data.Signer{Signer: partial.Signer})
+	}
+	if weight < s.SignerQuorum {
+		return nil, fmt.Errorf("multisign: signatures weigh %d of a quorum of %d", weight, s.SignerQuorum)
+	}
+	tx.GetBase().TxnSignature = nil
+	if err := data.SetSigners(tx.(data.MultiSignable), signers...); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Missing lists the signers who have not yet signed, heaviest first
+func (s *Signers) Missing(partials ...*Partial) []data.Account {
+	signed := make(map[data.Account]bool)
+	for _, partial := range partials {
+		signed[partial.Signer.Account] = true
+	}
+	var missing []data.Account
+	for account := range s.Weights {
+		if !signed[account] {
+			missing = append(missing, account)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if s.Weights[missing[i]] != s.Weights[missing[j]] {
+			return s.Weights[missing[i]] > s.Weights[missing[j]]
+		}
+		return missing[i].Less(missing[j])
+	})
+	return missing
+}
+
+// TxBlob encodes a combined transaction for submission
+func TxBlob(tx data.Transaction) (string, error) {
+	_, raw, err := data.Raw(tx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", raw), nil
+}
+
+func ReadUnsigned(r io.Reader) (*Unsigned, error) {
+	var u struct {
+		Hash   data.Hash256 `json:"hash"`
+		TxBlob string       `json:"tx_blob"`
+	}
+	if err := json.NewDecoder(r).Decode(&u); err != nil {
+		return nil, err
+	}
+	unsigned := &Unsigned{Hash: u.Hash, TxBlob: u.TxBlob}
+	tx, err := unsigned.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	unsigned.TxJson = tx
+	return unsigned, nil
+}
+
+func ReadPartial(r io.Reader) (*Partial, error) {
+	var partial Partial
+	if err := json.NewDecoder(r).Decode(&partial); err != nil {
+		return nil, err
+	}
+	return &partial, nil
+}
@@ -0,0 +1,164 @@
+package multisign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type MultiSignSuite struct{}
+
+var _ = Suite(&MultiSignSuite{})
+
+type testSigner struct {
+	key      crypto.Key
+	sequence *uint32
+	account  data.Account
+}
+
+func newTestSigner(c *C, password string, keyType data.KeyType) *testSigner {
+	hash, err := crypto.GenerateFamilySeed(password)
+	c.Assert(err, IsNil)
+	var seed data.Seed
+	copy(seed[:], hash.Payload())
+	signer := &testSigner{key: seed.Key(keyType)}
+	if keyType == data.ECDSA {
+		signer.sequence = new(uint32)
+	}
+	copy(signer.account[:], signer.key.Id(signer.sequence))
+	return signer
+}
+
+func (s *testSigner) sign(c *C, u *Unsigned) *Partial {
+	partial, err := u.Sign(s.key, s.sequence, s.account)
+	c.Assert(err, IsNil)
+	return partial
+}
+
+func newTestSigners(account data.Account, quorum uint32, signers ...*testSigner) *Signers {
+	s := &Signers{
+		Account:      account,
+		SignerQuorum: quorum,
+		Weights:      make(map[data.Account]uint16),
+		RegularKeys:  make(map[data.Account]data.RegularKey),
+		Flags:        make(map[data.Account]data.LedgerEntryFlag),
+	}
+	for _, signer := range signers {
+		s.Weights[signer.account] = 1
+	}
+	return s
+}
+
+func newTestUnsigned(c *C, account data.Account) *Unsigned {
+	destination, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	amount, err := data.NewAmount("1000000")
+	c.Assert(err, IsNil)
+	fee, err := data.NewNativeValue(40)
+	c.Assert(err, IsNil)
+	u, err := Export(&data.Payment{
+		TxBase: data.TxBase{
+			TransactionType: data.PAYMENT,
+			Account:         account,
+			Sequence:        12,
+			Fee:             *fee,
+		},
+		Destination: *destination,
+		Amount:      *amount,
+	})
+	c.Assert(err, IsNil)
+	return u
+}
+
+func (s *MultiSignSuite) TestCombine(c *C) {
+	treasury := newTestSigner(c, "treasury", data.ECDSA)
+	alice := newTestSigner(c, "alice", data.ECDSA)
+	bob := newTestSigner(c, "bob", data.Ed25519)
+	carol := newTestSigner(c, "carol", data.ECDSA)
+	signers := newTestSigners(treasury.account, 2, alice, bob, carol)
+
+	// Round trip through the files handed to each signer
+	b, err := json.Marshal(newTestUnsigned(c, treasury.account))
+	c.Assert(err, IsNil)
+	u, err := ReadUnsigned(bytes.NewReader(b))
+	c.Assert(err, IsNil)
+	b, err = json.Marshal(bob.sign(c, u))
+	c.Assert(err, IsNil)
+	fromBob, err := ReadPartial(bytes.NewReader(b))
+	c.Assert(err, IsNil)
+	fromAlice := alice.sign(c, u)
+
+	_, err = signers.Combine(u, fromAlice)
+	c.Assert(err, ErrorMatches, "multisign: signatures weigh 1 of a quorum of 2")
+	c.Assert(signers.Missing(fromAlice), HasLen, 2)
+
+	tx, err := signers.Combine(u, fromBob, fromAlice)
+	c.Assert(err, IsNil)
+	blob, err := TxBlob(tx)
+	c.Assert(err, IsNil)
+	raw, err := hex.DecodeString(blob)
+	c.Assert(err, IsNil)
+	decoded, err := data.ReadTransaction(bytes.NewReader(raw))
+	c.Assert(err, IsNil)
+	base := decoded.GetBase()
+	c.Assert(base.SigningPubKey.IsZero(), Equals, true)
+	c.Assert(base.TxnSignature, IsNil)
+	c.Assert(base.Signers, HasLen, 2)
+	c.Assert(base.Signers[0].Signer.Account.Less(base.Signers[1].Signer.Account), Equals, true)
+	for _, signer := range base.Signers {
+		c.Assert(signers.Verify(decoded, &Partial{Hash: u.Hash, Signer: signer.Signer}), IsNil)
+	}
+	c.Assert(tx.GetHash().IsZero(), Equals, false)
+}
+
+func (s *MultiSignSuite) TestRejected(c *C) {
+	treasury := newTestSigner(c, "treasury", data.ECDSA)
+	alice := newTestSigner(c, "alice", data.ECDSA)
+	bob := newTestSigner(c, "bob", data.Ed25519)
+	mallory := newTestSigner(c, "mallory", data.ECDSA)
+	signers := newTestSigners(treasury.account, 2, alice, bob)
+	u := newTestUnsigned(c, treasury.account)
+	fromAlice := alice.sign(c, u)
+
+	_, err := signers.Combine(u, fromAlice, fromAlice)
+	c.Assert(err, ErrorMatches, ".*signed more than once")
+
+	_, err = signers.Combine(u, fromAlice, mallory.sign(c, u))
+	c.Assert(err, ErrorMatches, ".*is not in the SignerList.*")
+
+	// Signing for bob without bob's key
+	impostor, err := u.Sign(mallory.key, mallory.sequence, bob.account)
+	c.Assert(err, IsNil)
+	_, err = signers.Combine(u, fromAlice, impostor)
+	c.Assert(err, ErrorMatches, ".*signed with a key which is not theirs.*")
+	// Unless it is bob's regular key
+	signers.RegularKeys[bob.account] = data.RegularKey(mallory.account)
+	_, err = signers.Combine(u, fromAlice, impostor)
+	c.Assert(err, IsNil)
+	// Which is then the only key bob can use
+	signers.Flags[bob.account] = data.LsDisableMaster
+	_, err = signers.Combine(u, fromAlice, bob.sign(c, u))
+	c.Assert(err, ErrorMatches, ".*signed with its master key, which is disabled")
+	_, err = signers.Combine(u, fromAlice, impostor)
+	c.Assert(err, IsNil)
+	delete(signers.Flags, bob.account)
+
+	tampered := bob.sign(c, u)
+	(*tampered.Signer.TxnSignature)[10] ^= 0xFF
+	_, err = signers.Combine(u, fromAlice, tampered)
+	c.Assert(err, ErrorMatches, ".*has a bad signature")
+
+	other := newTestUnsigned(c, treasury.account)
+	other.TxJson.GetBase().Sequence = 13
+	other, err = Export(other.TxJson)
+	c.Assert(err, IsNil)
+	_, err = signers.Combine(u, fromAlice, bob.sign(c, other))
+	c.Assert(err, ErrorMatches, ".*signed a different transaction.*")
+}
@@ -0,0 +1,381 @@
+package peers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+const (
+	defaultBatch   = 256
+	defaultTimeout = 30 * time.Second
+	// The depth of a leaf whose key is a full path
+	maxDepth = 64
+)
+
+// nodeID is a SHAMap node id, the path from the root to a node and its
+// depth. Its wire format is the path, zeroed beyond the depth, followed by
+// the depth.
+type nodeID struct {
+	path  data.Hash256
+	depth uint8
+}
+
+func parseNodeID(b []byte) (nodeID, error) {
+	var id nodeID
+	if len(b) != len(id.path)+1 || b[len(id.path)] > maxDepth {
+		return id, fmt.Errorf("peers: bad node id: %X", b)
+	}
+	copy(id.path[:], b)
+	id.depth = b[len(id.path)]
+	if !id.contains(id.path) {
+		return id, fmt.Errorf("peers: bad node id: %X", b)
+	}
+	return id, nil
+}
+
+func (id nodeID) Bytes() []byte {
+	return append(id.path[:len(id.path):len(id.path)], id.depth)
+}
+
+// Returns key zeroed beyond the depth
+func (id nodeID) mask(key data.Hash256) data.Hash256 {
+	var masked data.Hash256
+	copy(masked[:id.depth/2], key[:id.depth/2])
+	if id.depth%2 == 1 {
+		masked[id.depth/2] = key[id.depth/2] & 0xF0
+	}
+	return masked
+}
+
+// Whether the node is on the path to key
+func (id nodeID) contains(key data.Hash256) bool {
+	return id.mask(key) == id.path
+}
+
+func (id nodeID) child(branch int) nodeID {
+	child := nodeID{path: id.path, depth: id.depth + 1}
+	if id.depth%2 == 0 {
+		child.path[id.depth/2] |= byte(branch) << 4
+	} else {
+		child.path[id.depth/2] |= byte(branch)
+	}
+	return child
+}
+
+// The hash of a node in wire format, which is that of its prefix format
+func wireHash(b []byte, node data.Hashable) (data.Hash256, error) {
+	var prefix data.HashPrefix
+	switch data.WireType(b[len(b)-1]) {
+	case data.WT_ACCOUNT_STATE:
+		prefix = data.HP_LEAF_NODE
+	case data.WT_TRANSACTION_WITH_META:
+		prefix = data.HP_TRANSACTION_NODE
+	case data.WT_TRANSACTION:
+		prefix = data.HP_TRANSACTION_ID
+	default:
+		return data.NodeId(node)
+	}
+	var hash data.Hash256
+	copy(hash[:], crypto.Sha512Half(append(prefix.Bytes(), b[:len(b)-1]...)))
+	return hash, nil
+}
+
+// An account state or transaction tree being acquired. Nodes are only
+// accepted once their parent has been, and then only if their hash is the
+// one the parent holds.
+type tree struct {
+	name     string
+	infoType LedgerInfoType
+	nodeType data.NodeType
+	ledger   *data.Ledger
+	work     data.Work
+	wanted   map[nodeID]data.Hash256
+	ids      map[data.Hash256]nodeID
+	leaves   []data.Hashable
+}
+
+func newTree(ledger *data.Ledger, infoType LedgerInfoType) *tree {
+	t := &tree{
+		infoType: infoType,
+		ledger:   ledger,
+		work: data.Work{
+			LedgerRange: &data.LedgerRange{
+				Start: ledger.LedgerSequence,
+				End:   ledger.LedgerSequence,
+				Max:   1,
+			},
+		},
+		wanted: make(map[nodeID]data.Hash256),
+		ids:    make(map[data.Hash256]nodeID),
+	}
+	root := ledger.StateHash
+	t.name, t.nodeType = "account state", data.NT_ACCOUNT_NODE
+	if infoType == LiTransactionNode {
+		root = ledger.TransactionHash
+		t.name, t.nodeType = "transaction", data.NT_TRANSACTION_NODE
+	}
+	if !root.IsZero() {
+		t.want(nodeID{}, root)
+	}
+	return t
+}
+
+func (t *tree) want(id nodeID, hash data.Hash256) {
+	t.wanted[id] = hash
+	t.ids[hash] = id
+	t.work.MissingNodes = append(t.work.MissingNodes, hash)
+}
+
+// Verifies and adds a node which is wanted
+func (t *tree) add(id nodeID, b []byte) error {
+	expected := t.wanted[id]
+	if len(b) == 0 {
+		return fmt.Errorf("peers: empty %s node", t.name)
+	}
+	node, err := data.ReadWire(bytes.NewReader(b), t.nodeType, t.ledger.LedgerSequence, expected)
+	if err != nil {
+		return fmt.Errorf("peers: bad %s node %X: %s", t.name, id.Bytes(), err)
+	}
+	hash, err := wireHash(b, node)
+	if err != nil {
+		return err
+	}
+	if hash != expected {
+		return fmt.Errorf("peers: %s node %X has hash %s expected: %s", t.name, id.Bytes(), hash, expected)
+	}
+	delete(t.wanted, id)
+	switch v := node.(type) {
+	case *data.InnerNode:
+		if id.depth >= maxDepth {
+			return fmt.Errorf("peers: %s inner node %X is too deep", t.name, id.Bytes())
+		}
+		return v.Each(func(branch int, child data.Hash256) error {
+			t.want(id.child(branch), child)
+			return nil
+		})
+	case data.LedgerEntry:
+		if t.infoType != LiAccountStateNode {
+			return fmt.Errorf("peers: ledger entry in %s tree", t.name)
+		}
+	case *data.TransactionWithMetaData:
+		if t.infoType != LiTransactionNode {
+			return fmt.Errorf("peers: transaction in %s tree", t.name)
+		}
+		v.Date = *t.ledger.CloseTime
+	default:
+		return fmt.Errorf("peers: unexpected %s node: %s", t.name, node.GetType())
+	}
+	if !id.contains(*node.GetHash()) {
+		return fmt.Errorf("peers: %s %s is not at %X", t.name, node.GetHash(), id.Bytes())
+	}
+	t.leaves = append(t.leaves, node)
+	return nil
+}
+
+// Adds the wanted nodes of a reply, in whatever order they arrived, and
+// returns how many there were
+func (t *tree) addAll(nodes []LedgerNode) (int, error) {
+	var added int
+	done := make([]bool, len(nodes))
+	for progress := true; progress; {
+		progress = false
+		for i, node := range nodes {
+			if done[i] {
+				continue
+			}
+			id, err := parseNodeID(node.NodeID)
+			if err != nil {
+				return added, err
+			}
+			if _, ok := t.wanted[id]; !ok {
+				continue
+			}
+			if err := t.add(id, node.Data); err != nil {
+				return added, err
+			}
+			done[i], progress = true, true
+			added++
+		}
+	}
+	missing := t.work.MissingNodes[:0]
+	for _, hash := range t.work.MissingNodes {
+		if _, ok := t.wanted[t.ids[hash]]; ok {
+			missing = append(missing, hash)
+		}
+	}
+	t.work.MissingNodes = missing
+	return added, nil
+}
+
+// Acquirer fetches ledgers from a peer and verifies every node, from the
+// header down to the leaves of the transaction and account state trees,
+// against the ledger hash. Messages other than the replies are discarded
+// while a ledger is acquired, so nothing else should read from the peer.
+type Acquirer struct {
+	// The most nodes asked for in one GetLedger
+	Batch int
+	// How long to wait for each reply
+	Timeout time.Duration
+
+	peer *Peer
+}
+
+func NewAcquirer(p *Peer) *Acquirer {
+	return &Acquirer{
+		Batch:   defaultBatch,
+		Timeout: defaultTimeout,
+		peer:    p,
+	}
+}
+
+// Sends a GetLedger and waits for its reply
+func (a *Acquirer) request(m *GetLedger) (*LedgerData, error) {
+	if err := a.peer.WriteMessage(m); err != nil {
+		return nil, err
+	}
+	defer a.peer.conn.SetReadDeadline(time.Time{})
+	for {
+		a.peer.conn.SetReadDeadline(time.Now().Add(a.Timeout))
+		reply, err := a.peer.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		ld, ok := reply.(*LedgerData)
+		if !ok || ld.LedgerHash != *m.LedgerHash || ld.InfoType != m.InfoType {
+			continue
+		}
+		if ld.Error != nil {
+			return nil, fmt.Errorf("peers: %s: %s", m.LedgerHash, *ld.Error)
+		}
+		return ld, nil
+	}
+}
+
+// Acquires and verifies a ledger's header. The roots of its trees are
+// returned with it if the peer sent them.
+func (a *Acquirer) base(hash data.Hash256) (*data.Ledger, []LedgerNode, error) {
+	reply, err := a.request(&GetLedger{InfoType: LiBase, LedgerHash: &hash})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(reply.Nodes) == 0 {
+		return nil, nil, fmt.Errorf("peers: no header for %s", hash)
+	}
+	ledger, err := data.ReadLedger(bytes.NewReader(reply.Nodes[0].Data), hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("peers: bad header for %s: %s", hash, err)
+	}
+	actual, err := data.NodeId(ledger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actual != hash {
+		return nil, nil, fmt.Errorf("peers: header for %s has hash %s", hash, actual)
+	}
+	ledger.Closed = true
+	return ledger, reply.Nodes[1:], nil
+}
+
+// Acquires the nodes of a tree until none are missing
+func (a *Acquirer) acquire(t *tree) error {
+	for len(t.work.MissingNodes) > 0 {
+		batch := t.work.MissingNodes
+		if len(batch) > a.Batch {
+			batch = batch[:a.Batch]
+		}
+		req := &GetLedger{
+			InfoType:   t.infoType,
+			LedgerHash: &t.ledger.Hash,
+			NodeIDs:    make([][]byte, len(batch)),
+		}
+		for i, hash := range batch {
+			req.NodeIDs[i] = t.ids[hash].Bytes()
+		}
+		reply, err := a.request(req)
+		if err != nil {
+			return err
+		}
+		added, err := t.addAll(reply.Nodes)
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			return fmt.Errorf("peers: %s sent none of %d %s nodes for %s", a.peer.PublicKey.NodePublicKey(), len(batch), t.name, t.ledger.Hash)
+		}
+	}
+	return nil
+}
+
+// Header acquires the header of the ledger with hash
+func (a *Acquirer) Header(hash data.Hash256) (*data.Ledger, error) {
+	ledger, _, err := a.base(hash)
+	return ledger, err
+}
+
+// Ledger acquires the ledger with hash along with its transactions, in the
+// order they were applied, and its account state, ordered by index
+func (a *Acquirer) Ledger(hash data.Hash256) (*data.Ledger, error) {
+	ledger, roots, err := a.base(hash)
+	if err != nil {
+		return nil, err
+	}
+	state, txs := newTree(ledger, LiAccountStateNode), newTree(ledger, LiTransactionNode)
+	// The state root comes first, then the transaction root, with no ids
+	for i, t := range []*tree{state, txs} {
+		if i < len(roots) && len(t.wanted) > 0 {
+			roots[i].NodeID = nodeID{}.Bytes()
+			if _, err := t.addAll(roots[i : i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, t := range []*tree{txs, state} {
+		if err := a.acquire(t); err != nil {
+			return nil, err
+		}
+	}
+	for _, leaf := range txs.leaves {
+		ledger.Transactions = append(ledger.Transactions, leaf.(*data.TransactionWithMetaData))
+	}
+	sort.Sort(ledger.Transactions)
+	for _, leaf := range state.leaves {
+		ledger.AccountState = append(ledger.AccountState, leaf.(data.LedgerEntry))
+	}
+	sort.Slice(ledger.AccountState, func(i, j int) bool {
+		return bytes.Compare(ledger.AccountState[i].GetHash()[:], ledger.AccountState[j].GetHash()[:]) < 0
+	})
+	return ledger, nil
+}
+
+// Backfill acquires the highest n ledgers missing from set at or below the
+// trusted ledger with hash, walking back from it through each ledger's
+// parent, so that every ledger is verified by the one after it. fn is
+// called with each ledger acquired, in descending order, which is then set.
+func (a *Acquirer) Backfill(set *data.LedgerSet, hash data.Hash256, n uint32, fn func(*data.Ledger) error) error {
+	ledger, err := a.Header(hash)
+	if err != nil {
+		return err
+	}
+	wanted := set.TakeMiddleTop(&data.LedgerRange{End: ledger.LedgerSequence, Max: n})
+	for i := len(wanted) - 1; i >= 0; i-- {
+		for ledger.LedgerSequence > wanted[i] {
+			if ledger, err = a.Header(ledger.PreviousLedger); err != nil {
+				return err
+			}
+		}
+		full, err := a.Ledger(ledger.Hash)
+		if err != nil {
+			return err
+		}
+		if err := fn(full); err != nil {
+			return err
+		}
+		set.Set(full.LedgerSequence)
+	}
+	return nil
+}
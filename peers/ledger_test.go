@@ -0,0 +1,265 @@
+package peers
+
+import (
+	"github.com/rubblelabs/ripple/data"
+	internal "github.com/rubblelabs/ripple/testing"
+	. "gopkg.in/check.v1"
+)
+
+type LedgerSuite struct{}
+
+var _ = Suite(&LedgerSuite{})
+
+type testLeaf struct {
+	key  data.Hash256
+	wire []byte
+}
+
+// A ledger served by a test peer, with its trees in wire format
+type testLedger struct {
+	*data.Ledger
+	header []byte
+	state  map[nodeID][]byte
+	txs    map[nodeID][]byte
+}
+
+func nibble(key data.Hash256, depth uint8) int {
+	if depth%2 == 0 {
+		return int(key[depth/2] >> 4)
+	}
+	return int(key[depth/2] & 0x0F)
+}
+
+// Builds a SHAMap as rippled does, with compressed inner nodes when they
+// have few children, and returns the hash of its root
+func buildTree(c *C, nodes map[nodeID][]byte, leaves []testLeaf, id nodeID) data.Hash256 {
+	if len(leaves) == 1 && id.depth > 0 {
+		nodes[id] = leaves[0].wire
+		hash, err := wireHash(leaves[0].wire, nil)
+		c.Assert(err, IsNil)
+		return hash
+	}
+	var branches [16][]testLeaf
+	for _, leaf := range leaves {
+		b := nibble(leaf.key, id.depth)
+		branches[b] = append(branches[b], leaf)
+	}
+	var inner data.InnerNode
+	var compressed []byte
+	for b, branch := range branches {
+		if len(branch) > 0 {
+			inner.Children[b] = buildTree(c, nodes, branch, id.child(b))
+			compressed = append(append(compressed, inner.Children[b][:]...), byte(b))
+		}
+	}
+	hash, full, err := data.Raw(&inner)
+	c.Assert(err, IsNil)
+	if inner.Count() < 12 {
+		nodes[id] = append(compressed, byte(data.WT_COMPRESSED_INNER))
+	} else {
+		nodes[id] = append(full, byte(data.WT_INNER))
+	}
+	return hash
+}
+
+// Builds a ledger from the test nodes
+func newTestLedger(c *C, sequence uint32, previous data.Hash256) *testLedger {
+	var state, txs []testLeaf
+	for _, test := range internal.Nodes {
+		nodeId, err := data.NewHash256(test.NodeId())
+		c.Assert(err, IsNil)
+		n, err := data.ReadPrefix(test.Reader(), *nodeId)
+		c.Assert(err, IsNil)
+		_, raw, err := data.Raw(n)
+		c.Assert(err, IsNil)
+		switch n.(type) {
+		case data.LedgerEntry:
+			state = append(state, testLeaf{*n.GetHash(), append(raw, byte(data.WT_ACCOUNT_STATE))})
+		case *data.TransactionWithMetaData:
+			txs = append(txs, testLeaf{*n.GetHash(), append(raw, byte(data.WT_TRANSACTION_WITH_META))})
+		}
+	}
+	c.Assert(len(state) > 1, Equals, true)
+	c.Assert(len(txs) > 12, Equals, true)
+	l := &testLedger{
+		Ledger: &data.Ledger{
+			LedgerHeader: data.LedgerHeader{
+				LedgerSequence:  sequence,
+				TotalXRP:        99999999999000000,
+				PreviousLedger:  previous,
+				ParentCloseTime: data.NewRippleTime(750000000 + sequence*4 - 4),
+				CloseTime:       data.NewRippleTime(750000000 + sequence*4),
+				CloseResolution: 10,
+			},
+		},
+		state: make(map[nodeID][]byte),
+		txs:   make(map[nodeID][]byte),
+	}
+	l.StateHash = buildTree(c, l.state, state, nodeID{})
+	l.TransactionHash = buildTree(c, l.txs, txs, nodeID{})
+	var err error
+	l.Hash, l.header, err = data.Raw(l.Ledger)
+	c.Assert(err, IsNil)
+	return l
+}
+
+// Answers GetLedgers, sending the children of each node asked for too
+func serveLedgers(p *Peer, ledgers map[data.Hash256]*testLedger) {
+	for {
+		m, err := p.ReadMessage()
+		if err != nil {
+			return
+		}
+		req, ok := m.(*GetLedger)
+		if !ok {
+			continue
+		}
+		reply := &LedgerData{LedgerHash: *req.LedgerHash, InfoType: req.InfoType}
+		l, ok := ledgers[*req.LedgerHash]
+		if !ok {
+			e := ReNoLedger
+			reply.Error = &e
+			p.WriteMessage(reply)
+			continue
+		}
+		reply.LedgerSequence = l.LedgerSequence
+		switch req.InfoType {
+		case LiBase:
+			reply.Nodes = []LedgerNode{{Data: l.header}, {Data: l.state[nodeID{}]}, {Data: l.txs[nodeID{}]}}
+		default:
+			nodes := l.state
+			if req.InfoType == LiTransactionNode {
+				nodes = l.txs
+			}
+			for _, b := range req.NodeIDs {
+				id, err := parseNodeID(b)
+				if err != nil || nodes[id] == nil {
+					continue
+				}
+				reply.Nodes = append(reply.Nodes, LedgerNode{Data: nodes[id], NodeID: b})
+				for branch := 0; branch < 16 && id.depth < maxDepth; branch++ {
+					if child := id.child(branch); nodes[child] != nil {
+						reply.Nodes = append(reply.Nodes, LedgerNode{Data: nodes[child], NodeID: child.Bytes()})
+					}
+				}
+			}
+		}
+		p.WriteMessage(reply)
+	}
+}
+
+func acquirer(c *C, ledgers ...*testLedger) (*Acquirer, func()) {
+	inbound, outbound, err := connect(c, nil, nil)
+	c.Assert(err, IsNil)
+	served := make(map[data.Hash256]*testLedger)
+	for _, l := range ledgers {
+		served[l.Hash] = l
+	}
+	go serveLedgers(inbound, served)
+	return NewAcquirer(outbound), func() {
+		outbound.Close()
+		inbound.Close()
+	}
+}
+
+func (s *LedgerSuite) TestNodeID(c *C) {
+	root := nodeID{}
+	child := root.child(0xA).child(0x5).child(0xC)
+	c.Assert(child.depth, Equals, uint8(3))
+	c.Assert(child.path[:2], DeepEquals, []byte{0xA5, 0xC0})
+	parsed, err := parseNodeID(child.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(parsed, Equals, child)
+	key := data.Hash256{0xA5, 0xCF}
+	c.Assert(child.contains(key), Equals, true)
+	c.Assert(root.contains(key), Equals, true)
+	c.Assert(root.child(0xB).contains(key), Equals, false)
+
+	_, err = parseNodeID(root.Bytes()[1:])
+	c.Assert(err, ErrorMatches, "peers: bad node id: .*")
+	bad := child.Bytes()
+	bad[1] |= 0x01
+	_, err = parseNodeID(bad)
+	c.Assert(err, ErrorMatches, "peers: bad node id: .*")
+}
+
+func (s *LedgerSuite) TestLedger(c *C) {
+	l := newTestLedger(c, 100, data.Hash256{1})
+	a, closer := acquirer(c, l)
+	defer closer()
+	a.Batch = 4
+
+	ledger, err := a.Ledger(l.Hash)
+	c.Assert(err, IsNil)
+	c.Assert(ledger.Hash, Equals, l.Hash)
+	c.Assert(ledger.LedgerHeader, DeepEquals, l.LedgerHeader)
+	c.Assert(ledger.Closed, Equals, true)
+	c.Assert(len(ledger.AccountState) > 1, Equals, true)
+	c.Assert(len(ledger.Transactions) > 12, Equals, true)
+	for i, le := range ledger.AccountState[1:] {
+		c.Assert(ledger.AccountState[i].GetHash().String() < le.GetHash().String(), Equals, true)
+	}
+	for _, txm := range ledger.Transactions {
+		c.Assert(txm.LedgerSequence, Equals, uint32(100))
+		c.Assert(txm.Date, Equals, *l.CloseTime)
+	}
+
+	header, err := a.Header(l.Hash)
+	c.Assert(err, IsNil)
+	c.Assert(header.LedgerHeader, DeepEquals, l.LedgerHeader)
+	c.Assert(header.Transactions, IsNil)
+
+	_, err = a.Ledger(data.Hash256{2})
+	c.Assert(err, ErrorMatches, "peers: 0200.*: no ledger")
+}
+
+func (s *LedgerSuite) TestTampered(c *C) {
+	l := newTestLedger(c, 100, data.Hash256{1})
+	// Swap two leaves, which are still valid nodes
+	var leaves []nodeID
+	for id, b := range l.state {
+		if data.WireType(b[len(b)-1]) == data.WT_ACCOUNT_STATE {
+			leaves = append(leaves, id)
+		}
+	}
+	l.state[leaves[0]], l.state[leaves[1]] = l.state[leaves[1]], l.state[leaves[0]]
+	a, closer := acquirer(c, l)
+	defer closer()
+	_, err := a.Ledger(l.Hash)
+	c.Assert(err, ErrorMatches, "peers: account state node .* has hash .* expected: .*")
+
+	// A header which is not the one asked for
+	forged := newTestLedger(c, 101, data.Hash256{1})
+	forged.header = l.header
+	a, closer = acquirer(c, forged)
+	defer closer()
+	_, err = a.Header(forged.Hash)
+	c.Assert(err, ErrorMatches, "peers: header for .* has hash .*")
+}
+
+func (s *LedgerSuite) TestBackfill(c *C) {
+	var chain []*testLedger
+	var previous data.Hash256
+	for seq := uint32(10); seq <= 13; seq++ {
+		l := newTestLedger(c, seq, previous)
+		chain = append(chain, l)
+		previous = l.Hash
+	}
+	a, closer := acquirer(c, chain...)
+	defer closer()
+
+	set := data.NewLedgerSet(10, 14)
+	set.Set(11)
+	var acquired []uint32
+	fn := func(l *data.Ledger) error {
+		c.Assert(len(l.Transactions) > 0, Equals, true)
+		acquired = append(acquired, l.LedgerSequence)
+		return nil
+	}
+	// The missing ledgers nearest the trusted one come first
+	c.Assert(a.Backfill(set, chain[2].Hash, 1, fn), IsNil)
+	c.Assert(acquired, DeepEquals, []uint32{12})
+	c.Assert(a.Backfill(set, chain[2].Hash, 10, fn), IsNil)
+	c.Assert(acquired, DeepEquals, []uint32{12, 10})
+	c.Assert(set.TakeBottom(10), DeepEquals, data.LedgerSlice{13})
+}
@@ -0,0 +1,553 @@
+package peers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// MessageType is the type in an overlay message header, from ripple.proto
+type MessageType uint16
+
+const (
+	MtManifests               MessageType = 2
+	MtPing                    MessageType = 3
+	MtCluster                 MessageType = 5
+	MtEndpoints               MessageType = 15
+	MtTransaction             MessageType = 30
+	MtGetLedger               MessageType = 31
+	MtLedgerData              MessageType = 32
+	MtProposeLedger           MessageType = 33
+	MtStatusChange            MessageType = 34
+	MtHaveSet                 MessageType = 35
+	MtValidation              MessageType = 41
+	MtGetObjects              MessageType = 42
+	MtValidatorList           MessageType = 54
+	MtSquelch                 MessageType = 55
+	MtValidatorListCollection MessageType = 56
+	MtProofPathRequest        MessageType = 57
+	MtProofPathResponse       MessageType = 58
+	MtReplayDeltaRequest      MessageType = 59
+	MtReplayDeltaResponse     MessageType = 60
+	MtHaveTransactions        MessageType = 63
+	MtTransactions            MessageType = 64
+)
+
+var messageTypeNames = map[MessageType]string{
+	MtManifests:               "Manifests",
+	MtPing:                    "Ping",
+	MtCluster:                 "Cluster",
+	MtEndpoints:               "Endpoints",
+	MtTransaction:             "Transaction",
+	MtGetLedger:               "GetLedger",
+	MtLedgerData:              "LedgerData",
+	MtProposeLedger:           "ProposeLedger",
+	MtStatusChange:            "StatusChange",
+	MtHaveSet:                 "HaveSet",
+	MtValidation:              "Validation",
+	MtGetObjects:              "GetObjects",
+	MtValidatorList:           "ValidatorList",
+	MtSquelch:                 "Squelch",
+	MtValidatorListCollection: "ValidatorListCollection",
+	MtProofPathRequest:        "ProofPathRequest",
+	MtProofPathResponse:       "ProofPathResponse",
+	MtReplayDeltaRequest:      "ReplayDeltaRequest",
+	MtReplayDeltaResponse:     "ReplayDeltaResponse",
+	MtHaveTransactions:        "HaveTransactions",
+	MtTransactions:            "Transactions",
+}
+
+func (t MessageType) String() string {
+	if name, ok := messageTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", uint16(t))
+}
+
+// Message is a protobuf message carried by the overlay
+type Message interface {
+	Type() MessageType
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+const (
+	headerSize = 6
+	// rippled drops peers which send anything larger
+	maximumMessageSize = 64 << 20
+)
+
+var messageFactory = map[MessageType]func() Message{
+	MtManifests:     func() Message { return &Manifests{} },
+	MtPing:          func() Message { return &Ping{} },
+	MtTransaction:   func() Message { return &Transaction{} },
+	MtGetLedger:     func() Message { return &GetLedger{} },
+	MtLedgerData:    func() Message { return &LedgerData{} },
+	MtProposeLedger: func() Message { return &ProposeSet{} },
+	MtValidation:    func() Message { return &Validation{} },
+}
+
+// ReadMessage reads one uncompressed message. Types without a decoder are
+// returned as *Unknown.
+func ReadMessage(r io.Reader) (Message, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0]&0x80 != 0 {
+		return nil, fmt.Errorf("peers: compressed messages are not supported")
+	}
+	if header[0]&0xFC != 0 {
+		return nil, fmt.Errorf("peers: bad message header: %X", header)
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size > maximumMessageSize {
+		return nil, fmt.Errorf("peers: message too large: %d", size)
+	}
+	typ := MessageType(binary.BigEndian.Uint16(header[4:]))
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	factory, ok := messageFactory[typ]
+	if !ok {
+		return &Unknown{MessageType: typ, Payload: payload}, nil
+	}
+	m := factory()
+	if err := m.Unmarshal(payload); err != nil {
+		return nil, fmt.Errorf("peers: %s: %s", typ, err)
+	}
+	return m, nil
+}
+
+// WriteMessage frames and writes a message in a single write
+func WriteMessage(w io.Writer, m Message) error {
+	payload, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	if len(payload) > maximumMessageSize {
+		return fmt.Errorf("peers: message too large: %d", len(payload))
+	}
+	b := make([]byte, headerSize, headerSize+len(payload))
+	binary.BigEndian.PutUint32(b, uint32(len(payload)))
+	binary.BigEndian.PutUint16(b[4:], uint16(m.Type()))
+	_, err = w.Write(append(b, payload...))
+	return err
+}
+
+// Unknown is a message which is passed through undecoded
+type Unknown struct {
+	MessageType MessageType
+	Payload     []byte
+}
+
+func (m *Unknown) Type() MessageType        { return m.MessageType }
+func (m *Unknown) Marshal() ([]byte, error) { return m.Payload, nil }
+func (m *Unknown) Unmarshal(b []byte) error { m.Payload = b; return nil }
+
+// Manifests is TMManifests
+type Manifests struct {
+	Manifests []*data.Manifest
+}
+
+func (m *Manifests) Type() MessageType { return MtManifests }
+
+func (m *Manifests) Marshal() ([]byte, error) {
+	var w protoWriter
+	for _, manifest := range m.Manifests {
+		_, raw, err := data.Raw(manifest)
+		if err != nil {
+			return nil, err
+		}
+		// TMManifest
+		var inner protoWriter
+		inner.bytes(1, raw)
+		w.bytes(1, inner)
+	}
+	return w, nil
+}
+
+func (m *Manifests) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		if f.number != 1 {
+			return nil
+		}
+		return readProto(f.bytes, func(f *protoField) error {
+			if f.number != 1 {
+				return nil
+			}
+			manifest, err := data.ReadManifest(bytes.NewReader(f.bytes))
+			if err != nil {
+				return err
+			}
+			m.Manifests = append(m.Manifests, manifest)
+			return nil
+		})
+	})
+}
+
+// Ping is TMPing. A peer answers a ping with a pong carrying the same
+// Sequence.
+type Ping struct {
+	Pong     bool
+	Sequence *uint32
+	PingTime *uint64
+	NetTime  *uint64
+}
+
+func (m *Ping) Type() MessageType { return MtPing }
+
+func (m *Ping) Marshal() ([]byte, error) {
+	var w protoWriter
+	if m.Pong {
+		w.varint(1, 1)
+	} else {
+		w.varint(1, 0)
+	}
+	w.optionalUint32(2, m.Sequence)
+	w.optionalUint64(3, m.PingTime)
+	w.optionalUint64(4, m.NetTime)
+	return w, nil
+}
+
+func (m *Ping) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			m.Pong = f.value == 1
+		case 2:
+			m.Sequence = f.uint32()
+		case 3:
+			m.PingTime = f.uint64()
+		case 4:
+			m.NetTime = f.uint64()
+		}
+		return nil
+	})
+}
+
+// TransactionStatus is TransactionStatus from ripple.proto
+type TransactionStatus uint32
+
+const (
+	TsNew TransactionStatus = iota + 1
+	TsCurrent
+	TsCommitted
+	TsRejectConflict
+	TsRejectInvalid
+	TsRejectFunds
+	TsHeldSequence
+	TsHeldLedger
+)
+
+// Transaction is TMTransaction
+type Transaction struct {
+	Transaction      data.Transaction
+	Status           TransactionStatus
+	ReceiveTimestamp *uint64
+	Deferred         *bool
+}
+
+func (m *Transaction) Type() MessageType { return MtTransaction }
+
+func (m *Transaction) Marshal() ([]byte, error) {
+	_, raw, err := data.Raw(m.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	var w protoWriter
+	w.bytes(1, raw)
+	w.varint(2, uint64(m.Status))
+	w.optionalUint64(3, m.ReceiveTimestamp)
+	w.optionalBool(4, m.Deferred)
+	return w, nil
+}
+
+func (m *Transaction) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			tx, err := data.ReadTransaction(bytes.NewReader(f.bytes))
+			if err != nil {
+				return err
+			}
+			hash, err := data.NodeId(tx)
+			if err != nil {
+				return err
+			}
+			*tx.GetHash() = hash
+			m.Transaction = tx
+		case 2:
+			m.Status = TransactionStatus(f.value)
+		case 3:
+			m.ReceiveTimestamp = f.uint64()
+		case 4:
+			m.Deferred = f.bool()
+		}
+		return nil
+	})
+}
+
+// ProposeSet is TMProposeSet, a validator's proposed transaction set
+type ProposeSet struct {
+	Proposal data.Proposal
+	Hops     *uint32
+}
+
+func (m *ProposeSet) Type() MessageType { return MtProposeLedger }
+
+func (m *ProposeSet) Marshal() ([]byte, error) {
+	p := &m.Proposal
+	var w protoWriter
+	w.varint(1, uint64(p.Sequence))
+	w.bytes(2, p.LedgerHash.Bytes())
+	w.bytes(3, p.PublicKey.Bytes())
+	w.varint(4, uint64(p.CloseTime.Uint32()))
+	w.bytes(5, p.Signature.Bytes())
+	w.bytes(6, p.PreviousLedger.Bytes())
+	w.optionalUint32(12, m.Hops)
+	return w, nil
+}
+
+func (m *ProposeSet) Unmarshal(b []byte) error {
+	p := &m.Proposal
+	err := readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			p.Sequence = uint32(f.value)
+		case 2:
+			return f.hash("currentTxHash", p.LedgerHash.Bytes())
+		case 3:
+			return f.hash("nodePubKey", p.PublicKey.Bytes())
+		case 4:
+			p.CloseTime.SetUint32(uint32(f.value))
+		case 5:
+			p.Signature = data.VariableLength(f.bytes)
+		case 6:
+			return f.hash("previousledger", p.PreviousLedger.Bytes())
+		case 12:
+			m.Hops = f.uint32()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.Hash, err = data.NodeId(p)
+	return err
+}
+
+// Validation is TMValidation
+type Validation struct {
+	Validation *data.Validation
+	Hops       *uint32
+}
+
+func (m *Validation) Type() MessageType { return MtValidation }
+
+func (m *Validation) Marshal() ([]byte, error) {
+	_, raw, err := data.Raw(m.Validation)
+	if err != nil {
+		return nil, err
+	}
+	var w protoWriter
+	w.bytes(1, raw)
+	w.optionalUint32(3, m.Hops)
+	return w, nil
+}
+
+func (m *Validation) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			v, err := data.ReadValidation(bytes.NewReader(f.bytes))
+			if err != nil {
+				return err
+			}
+			if v.Hash, err = data.NodeId(v); err != nil {
+				return err
+			}
+			m.Validation = v
+		case 3:
+			m.Hops = f.uint32()
+		}
+		return nil
+	})
+}
+
+// LedgerInfoType is TMLedgerInfoType, what a GetLedger asks for
+type LedgerInfoType uint32
+
+const (
+	LiBase LedgerInfoType = iota
+	LiTransactionNode
+	LiAccountStateNode
+	LiTransactionSetCandidate
+)
+
+// LedgerType is TMLedgerType, which ledger a GetLedger without a hash or
+// sequence asks for
+type LedgerType uint32
+
+const (
+	LtAccepted LedgerType = iota
+	LtCurrent
+	LtClosed
+)
+
+// ReplyError is TMReplyError
+type ReplyError uint32
+
+const (
+	ReNoLedger ReplyError = iota + 1
+	ReNoNode
+	ReBadRequest
+)
+
+func (e ReplyError) String() string {
+	switch e {
+	case ReNoLedger:
+		return "no ledger"
+	case ReNoNode:
+		return "no node"
+	case ReBadRequest:
+		return "bad request"
+	default:
+		return fmt.Sprintf("error %d", uint32(e))
+	}
+}
+
+// GetLedger is TMGetLedger
+type GetLedger struct {
+	InfoType       LedgerInfoType
+	LedgerType     *LedgerType
+	LedgerHash     *data.Hash256
+	LedgerSequence *uint32
+	NodeIDs        [][]byte
+	RequestCookie  *uint64
+	QueryType      *uint32
+	QueryDepth     *uint32
+}
+
+func (m *GetLedger) Type() MessageType { return MtGetLedger }
+
+func (m *GetLedger) Marshal() ([]byte, error) {
+	var w protoWriter
+	w.varint(1, uint64(m.InfoType))
+	if m.LedgerType != nil {
+		w.varint(2, uint64(*m.LedgerType))
+	}
+	if m.LedgerHash != nil {
+		w.bytes(3, m.LedgerHash.Bytes())
+	}
+	w.optionalUint32(4, m.LedgerSequence)
+	for _, id := range m.NodeIDs {
+		w.bytes(5, id)
+	}
+	w.optionalUint64(6, m.RequestCookie)
+	w.optionalUint32(7, m.QueryType)
+	w.optionalUint32(8, m.QueryDepth)
+	return w, nil
+}
+
+func (m *GetLedger) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			m.InfoType = LedgerInfoType(f.value)
+		case 2:
+			typ := LedgerType(f.value)
+			m.LedgerType = &typ
+		case 3:
+			m.LedgerHash = new(data.Hash256)
+			return f.hash("ledgerHash", m.LedgerHash.Bytes())
+		case 4:
+			m.LedgerSequence = f.uint32()
+		case 5:
+			m.NodeIDs = append(m.NodeIDs, f.bytes)
+		case 6:
+			m.RequestCookie = f.uint64()
+		case 7:
+			m.QueryType = f.uint32()
+		case 8:
+			m.QueryDepth = f.uint32()
+		}
+		return nil
+	})
+}
+
+// LedgerNode is TMLedgerNode, a node in its wire format with its SHAMap
+// node id
+type LedgerNode struct {
+	Data   []byte
+	NodeID []byte
+}
+
+// LedgerData is TMLedgerData, the reply to a GetLedger
+type LedgerData struct {
+	LedgerHash     data.Hash256
+	LedgerSequence uint32
+	InfoType       LedgerInfoType
+	Nodes          []LedgerNode
+	RequestCookie  *uint32
+	Error          *ReplyError
+}
+
+func (m *LedgerData) Type() MessageType { return MtLedgerData }
+
+func (m *LedgerData) Marshal() ([]byte, error) {
+	var w protoWriter
+	w.bytes(1, m.LedgerHash.Bytes())
+	w.varint(2, uint64(m.LedgerSequence))
+	w.varint(3, uint64(m.InfoType))
+	for _, node := range m.Nodes {
+		var inner protoWriter
+		inner.bytes(1, node.Data)
+		if node.NodeID != nil {
+			inner.bytes(2, node.NodeID)
+		}
+		w.bytes(4, inner)
+	}
+	w.optionalUint32(5, m.RequestCookie)
+	if m.Error != nil {
+		w.varint(6, uint64(*m.Error))
+	}
+	return w, nil
+}
+
+func (m *LedgerData) Unmarshal(b []byte) error {
+	return readProto(b, func(f *protoField) error {
+		switch f.number {
+		case 1:
+			return f.hash("ledgerHash", m.LedgerHash.Bytes())
+		case 2:
+			m.LedgerSequence = uint32(f.value)
+		case 3:
+			m.InfoType = LedgerInfoType(f.value)
+		case 4:
+			var node LedgerNode
+			if err := readProto(f.bytes, func(f *protoField) error {
+				switch f.number {
+				case 1:
+					node.Data = f.bytes
+				case 2:
+					node.NodeID = f.bytes
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			m.Nodes = append(m.Nodes, node)
+		case 5:
+			m.RequestCookie = f.uint32()
+		case 6:
+			e := ReplyError(f.value)
+			m.Error = &e
+		}
+		return nil
+	})
+}
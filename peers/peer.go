@@ -0,0 +1,371 @@
+// Package peers connects nodes with messages modelled on rippled's peer
+// protocol. A connection is a TLS session, upgraded from HTTP to XRPL/2.x,
+// over which each end proves its node key by signing a value bound to the
+// session. Protobuf messages are then exchanged in both directions. Only
+// connections between two ends of this package are tested: the session
+// signature has not been checked against a handshake with rippled.
+package peers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// The versions offered, which must all be XRPL/2.x
+var protocols = []string{"XRPL/2.1", "XRPL/2.2"}
+
+const (
+	handshakeTimeout = 30 * time.Second
+	// How far the remote Network-Time may be from ours
+	clockTolerance = 20 * time.Second
+)
+
+// Config describes the local end of a connection
+type Config struct {
+	// The node key, a secp256k1 key used without a sequence. A random one
+	// is used if nil.
+	Key crypto.Key
+	// Checked against the remote's Network-ID, which is 0 for the main
+	// network
+	NetworkID uint32
+	UserAgent string
+	// Presented when listening. Self-signed if nil.
+	Certificate *tls.Certificate
+}
+
+// Returns a copy with the defaults filled in
+func (c *Config) prepare(server bool) (*Config, error) {
+	var prepared Config
+	if c != nil {
+		prepared = *c
+	}
+	if prepared.Key == nil {
+		key, err := crypto.NewECDSAKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		prepared.Key = key
+	}
+	if public := prepared.Key.Public(nil); len(public) != 33 || (public[0] != 0x02 && public[0] != 0x03) {
+		return nil, fmt.Errorf("peers: node key must be secp256k1")
+	}
+	if prepared.UserAgent == "" {
+		prepared.UserAgent = "rubblelabs-ripple"
+	}
+	if server && prepared.Certificate == nil {
+		cert, err := selfSignedCertificate()
+		if err != nil {
+			return nil, err
+		}
+		prepared.Certificate = cert
+	}
+	return &prepared, nil
+}
+
+// Peer is an established connection
+type Peer struct {
+	// The remote node key
+	PublicKey data.PublicKey
+	// The negotiated protocol, ie. XRPL/2.2
+	Protocol string
+	Inbound  bool
+	// The remote's handshake headers
+	Header http.Header
+
+	conn   *tls.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+}
+
+// Dial connects to a peer at a host:port address
+func Dial(addr string, config *Config) (*Peer, error) {
+	config, err := config.prepare(false)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Client(conn, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Client performs the outbound handshake over conn
+func Client(conn net.Conn, config *Config) (*Peer, error) {
+	config, err := config.prepare(false)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+	rec := &recorder{Conn: conn}
+	tc := tls.Client(rec, tlsConfig(rec))
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	shared, err := rec.sharedValue(tc.ConnectionState(), true)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = conn.RemoteAddr().String()
+	req.Header = config.headers(shared)
+	req.Header.Set("User-Agent", config.UserAgent)
+	req.Header.Set("Upgrade", strings.Join(protocols, ", "))
+	if err := req.Write(tc); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(tc)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("peers: %s refused: %s %s", conn.RemoteAddr(), resp.Status, body)
+	}
+	protocol := negotiate(resp.Header.Get("Upgrade"))
+	if protocol == "" {
+		return nil, fmt.Errorf("peers: unsupported protocol: %s", resp.Header.Get("Upgrade"))
+	}
+	public, err := config.verify(resp.Header, shared)
+	if err != nil {
+		return nil, err
+	}
+	return &Peer{
+		PublicKey: *public,
+		Protocol:  protocol,
+		Header:    resp.Header,
+		conn:      tc,
+		reader:    reader,
+	}, nil
+}
+
+// Server performs the inbound handshake over conn
+func Server(conn net.Conn, config *Config) (*Peer, error) {
+	config, err := config.prepare(true)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+	defer conn.SetDeadline(time.Time{})
+	rec := &recorder{Conn: conn}
+	tlsConf := tlsConfig(rec)
+	tlsConf.Certificates = []tls.Certificate{*config.Certificate}
+	tc := tls.Server(rec, tlsConf)
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	shared, err := rec.sharedValue(tc.ConnectionState(), false)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(tc)
+	req, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, err
+	}
+	protocol := negotiate(req.Header.Get("Upgrade"))
+	if protocol == "" || !strings.EqualFold(req.Header.Get("Connect-As"), "Peer") {
+		refuse(tc, "Unsupported protocol or connection type")
+		return nil, fmt.Errorf("peers: unsupported upgrade: %s", req.Header.Get("Upgrade"))
+	}
+	public, err := config.verify(req.Header, shared)
+	if err != nil {
+		refuse(tc, err.Error())
+		return nil, err
+	}
+	w := bufio.NewWriter(tc)
+	h := config.headers(shared)
+	h.Set("Upgrade", protocol)
+	h.Set("Server", config.UserAgent)
+	fmt.Fprintf(w, "HTTP/1.1 101 Switching Protocols\r\n")
+	h.Write(w)
+	fmt.Fprintf(w, "\r\n")
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return &Peer{
+		PublicKey: *public,
+		Protocol:  protocol,
+		Inbound:   true,
+		Header:    req.Header,
+		conn:      tc,
+		reader:    reader,
+	}, nil
+}
+
+func refuse(w io.Writer, reason string) {
+	fmt.Fprintf(w, "HTTP/1.1 400 Bad Request\r\nConnection: close\r\nContent-Length: %d\r\n\r\n%s", len(reason), reason)
+}
+
+// Returns the highest XRPL/2.x protocol in a comma separated list which is
+// also offered here
+func negotiate(upgrade string) string {
+	var best string
+	for _, offered := range strings.Split(upgrade, ",") {
+		offered = strings.TrimSpace(offered)
+		for _, p := range protocols {
+			if offered == p && offered > best {
+				best = offered
+			}
+		}
+	}
+	return best
+}
+
+// The headers which identify this end of the connection
+func (c *Config) headers(shared []byte) http.Header {
+	signature, _ := crypto.Sign(c.Key.Private(nil), shared, nil)
+	public, _ := crypto.NodePublicKey(c.Key)
+	var cookie [8]byte
+	rand.Read(cookie[:])
+	h := make(http.Header)
+	h.Set("Connection", "Upgrade")
+	h.Set("Connect-As", "Peer")
+	h.Set("Crawl", "private")
+	h.Set("Network-ID", strconv.FormatUint(uint64(c.NetworkID), 10))
+	h.Set("Network-Time", strconv.FormatUint(uint64(data.Now().Uint32()), 10))
+	h.Set("Public-Key", public.String())
+	h.Set("Session-Signature", base64.StdEncoding.EncodeToString(signature))
+	h.Set("Instance-Cookie", strconv.FormatUint(binary.BigEndian.Uint64(cookie[:]), 10))
+	return h
+}
+
+// Checks the remote's headers and returns its node key
+func (c *Config) verify(h http.Header, shared []byte) (*data.PublicKey, error) {
+	if id := h.Get("Network-ID"); id != "" && id != strconv.FormatUint(uint64(c.NetworkID), 10) {
+		return nil, fmt.Errorf("peers: peer is on network %s", id)
+	}
+	if s := h.Get("Network-Time"); s != "" {
+		t, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("peers: bad Network-Time: %s", s)
+		}
+		offset := data.NewRippleTime(uint32(t)).Time().Sub(data.Now().Time())
+		if offset > clockTolerance || offset < -clockTolerance {
+			return nil, fmt.Errorf("peers: peer clock is %s out", offset)
+		}
+	}
+	key, err := crypto.NewRippleHashCheck(h.Get("Public-Key"), crypto.RIPPLE_NODE_PUBLIC)
+	if err != nil {
+		return nil, fmt.Errorf("peers: bad Public-Key: %s", err)
+	}
+	var public data.PublicKey
+	if len(key.Payload()) != len(public) {
+		return nil, fmt.Errorf("peers: bad Public-Key")
+	}
+	copy(public[:], key.Payload())
+	if public[0] != 0x02 && public[0] != 0x03 {
+		return nil, fmt.Errorf("peers: Public-Key must be secp256k1")
+	}
+	own := c.Key.Public(nil)
+	if string(own) == string(public[:]) {
+		return nil, fmt.Errorf("peers: connected to self")
+	}
+	signature, err := base64.StdEncoding.DecodeString(h.Get("Session-Signature"))
+	if err != nil {
+		return nil, fmt.Errorf("peers: bad Session-Signature: %s", err)
+	}
+	ok, err := crypto.Verify(public[:], shared, nil, signature)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("peers: bad Session-Signature")
+	}
+	return &public, nil
+}
+
+// ReadMessage returns the next message. Pings are answered before they
+// are returned.
+func (p *Peer) ReadMessage() (Message, error) {
+	m, err := ReadMessage(p.reader)
+	if err != nil {
+		return nil, err
+	}
+	if ping, ok := m.(*Ping); ok && !ping.Pong {
+		pong := *ping
+		pong.Pong = true
+		if err := p.WriteMessage(&pong); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// WriteMessage may be called concurrently with itself and ReadMessage
+func (p *Peer) WriteMessage(m Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return WriteMessage(p.conn, m)
+}
+
+func (p *Peer) RemoteAddr() net.Addr { return p.conn.RemoteAddr() }
+func (p *Peer) Close() error         { return p.conn.Close() }
+
+func (p *Peer) String() string {
+	direction := "outbound"
+	if p.Inbound {
+		direction = "inbound"
+	}
+	return fmt.Sprintf("%s %s %s %s", p.PublicKey.NodePublicKey(), p.RemoteAddr(), p.Protocol, direction)
+}
+
+// Listener accepts inbound peers
+type Listener struct {
+	listener net.Listener
+	config   *Config
+}
+
+// Listen listens on a host:port address. The node key and certificate are
+// shared by every connection.
+func Listen(addr string, config *Config) (*Listener, error) {
+	config, err := config.prepare(true)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{listener: l, config: config}, nil
+}
+
+// Accept waits for a connection and performs its handshake. A failed
+// handshake is returned as an error and the Listener remains usable.
+func (l *Listener) Accept() (*Peer, error) {
+	conn, err := l.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	p, err := Server(conn, l.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (l *Listener) Addr() net.Addr { return l.listener.Addr() }
+func (l *Listener) Close() error   { return l.listener.Close() }
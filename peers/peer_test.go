@@ -0,0 +1,184 @@
+package peers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/validator"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type PeerSuite struct{}
+
+var _ = Suite(&PeerSuite{})
+
+// Connects two in-process peers over loopback
+func connect(c *C, server, client *Config) (*Peer, *Peer, error) {
+	l, err := Listen("127.0.0.1:0", server)
+	c.Assert(err, IsNil)
+	defer l.Close()
+	type accepted struct {
+		peer *Peer
+		err  error
+	}
+	done := make(chan accepted)
+	go func() {
+		p, err := l.Accept()
+		done <- accepted{p, err}
+	}()
+	outbound, err := Dial(l.Addr().String(), client)
+	inbound := <-done
+	if err != nil {
+		return nil, nil, err
+	}
+	c.Assert(inbound.err, IsNil)
+	return inbound.peer, outbound, nil
+}
+
+func (s *PeerSuite) TestHandshake(c *C) {
+	serverKey, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	clientKey, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	inbound, outbound, err := connect(c, &Config{Key: serverKey}, &Config{Key: clientKey})
+	c.Assert(err, IsNil)
+	defer inbound.Close()
+	defer outbound.Close()
+
+	c.Assert(inbound.Inbound, Equals, true)
+	c.Assert(outbound.Inbound, Equals, false)
+	c.Assert(inbound.PublicKey.Bytes(), DeepEquals, clientKey.Public(nil))
+	c.Assert(outbound.PublicKey.Bytes(), DeepEquals, serverKey.Public(nil))
+	c.Assert(inbound.Protocol, Equals, "XRPL/2.2")
+	c.Assert(outbound.Protocol, Equals, "XRPL/2.2")
+	c.Assert(outbound.Header.Get("Connect-As"), Equals, "Peer")
+}
+
+func (s *PeerSuite) TestRefused(c *C) {
+	key, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	_, _, err = connect(c, &Config{Key: key}, &Config{Key: key})
+	c.Assert(err, ErrorMatches, "peers: .* refused: 400 Bad Request peers: connected to self")
+	_, _, err = connect(c, &Config{NetworkID: 1}, nil)
+	c.Assert(err, ErrorMatches, "peers: .* refused: 400 Bad Request peers: peer is on network 0")
+
+	ed25519, err := crypto.NewEd25519Key(nil)
+	c.Assert(err, IsNil)
+	_, err = Dial("127.0.0.1:1", &Config{Key: ed25519})
+	c.Assert(err, ErrorMatches, "peers: node key must be secp256k1")
+}
+
+func (s *PeerSuite) TestForgetKeyLog(c *C) {
+	r := &recorder{}
+	r.keyLog.WriteString("CLIENT_RANDOM 00 0102\n")
+	b := r.keyLog.Bytes()
+	_, err := r.sharedValue(tls.ConnectionState{}, true)
+	c.Assert(err, NotNil)
+	c.Assert(r.keyLog.Len(), Equals, 0)
+	c.Assert(b, DeepEquals, make([]byte, len(b)))
+}
+
+func (s *PeerSuite) TestNegotiate(c *C) {
+	c.Assert(negotiate("XRPL/2.0, XRPL/2.1,XRPL/2.2"), Equals, "XRPL/2.2")
+	c.Assert(negotiate("XRPL/2.1"), Equals, "XRPL/2.1")
+	c.Assert(negotiate("XRPL/2.0, RTXP/1.2"), Equals, "")
+}
+
+func (s *PeerSuite) TestMessages(c *C) {
+	inbound, outbound, err := connect(c, nil, nil)
+	c.Assert(err, IsNil)
+	defer inbound.Close()
+	defer outbound.Close()
+
+	key, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+
+	proposal := data.NewProposal(data.Hash256{2}, data.Hash256{1}, time.Now())
+	proposal.Sequence = 2
+	c.Assert(data.Sign(proposal, key, nil), IsNil)
+
+	validation := data.NewValidation(data.Hash256{3}, 3, time.Now())
+	c.Assert(data.Sign(validation, key, nil), IsNil)
+
+	tx := &data.AccountSet{TxBase: data.TxBase{TransactionType: data.ACCOUNT_SET, Sequence: 4}}
+	fee, err := data.NewNativeValue(10)
+	c.Assert(err, IsNil)
+	tx.Fee = *fee
+	copy(tx.Account[:], key.Id(new(uint32)))
+	c.Assert(data.Sign(tx, key, new(uint32)), IsNil)
+
+	keys, err := validator.NewKeys()
+	c.Assert(err, IsNil)
+	token, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	manifest, err := token.ReadManifest()
+	c.Assert(err, IsNil)
+
+	sequence, cookie, reply := uint32(5), uint64(6), ReNoNode
+	messages := []Message{
+		&ProposeSet{Proposal: *proposal},
+		&Validation{Validation: validation},
+		&Transaction{Transaction: tx, Status: TsNew},
+		&Manifests{Manifests: []*data.Manifest{manifest}},
+		&GetLedger{InfoType: LiAccountStateNode, LedgerSequence: &sequence, NodeIDs: [][]byte{{1, 2}}, RequestCookie: &cookie},
+		&LedgerData{LedgerHash: data.Hash256{4}, LedgerSequence: sequence, Nodes: []LedgerNode{{Data: []byte{3}}, {Data: []byte{4}, NodeID: []byte{5}}}, Error: &reply},
+		&Unknown{MessageType: MtStatusChange, Payload: []byte{8, 1}},
+	}
+	go func() {
+		for _, m := range messages {
+			c.Check(outbound.WriteMessage(m), IsNil)
+		}
+	}()
+	for _, sent := range messages {
+		m, err := inbound.ReadMessage()
+		c.Assert(err, IsNil)
+		c.Assert(m.Type(), Equals, sent.Type())
+		switch m := m.(type) {
+		case *ProposeSet:
+			c.Assert(m.Proposal, DeepEquals, *proposal)
+			ok, err := data.CheckSignature(&m.Proposal)
+			c.Assert(err, IsNil)
+			c.Assert(ok, Equals, true)
+		case *Validation:
+			c.Assert(m.Validation, DeepEquals, validation)
+			ok, err := data.CheckSignature(m.Validation)
+			c.Assert(err, IsNil)
+			c.Assert(ok, Equals, true)
+		case *Transaction:
+			c.Assert(m.Transaction, DeepEquals, data.Transaction(tx))
+			c.Assert(m.Status, Equals, TsNew)
+		case *Manifests:
+			c.Assert(m.Manifests, HasLen, 1)
+			c.Assert(m.Manifests[0].Hash, Equals, manifest.Hash)
+			c.Assert(data.CheckManifest(m.Manifests[0]), IsNil)
+		default:
+			c.Assert(m, DeepEquals, sent)
+		}
+	}
+
+	// Pings are answered
+	go func() {
+		_, err := inbound.ReadMessage()
+		c.Check(err, IsNil)
+	}()
+	c.Assert(outbound.WriteMessage(&Ping{Sequence: &sequence}), IsNil)
+	m, err := outbound.ReadMessage()
+	c.Assert(err, IsNil)
+	c.Assert(m, DeepEquals, &Ping{Pong: true, Sequence: &sequence})
+}
+
+func (s *PeerSuite) TestFraming(c *C) {
+	var buf bytes.Buffer
+	c.Assert(WriteMessage(&buf, &Ping{}), IsNil)
+	c.Assert(buf.Bytes(), DeepEquals, []byte{0, 0, 0, 2, 0, 3, 8, 0})
+	_, err := ReadMessage(bytes.NewReader([]byte{0x90, 0, 0, 2, 0, 3, 0, 0, 0, 2, 8, 0}))
+	c.Assert(err, ErrorMatches, "peers: compressed messages are not supported")
+	_, err = ReadMessage(bytes.NewReader([]byte{0, 0, 0, 1, 0, 41, 0xFF}))
+	c.Assert(err, ErrorMatches, "peers: Validation: .*")
+}
@@ -0,0 +1,132 @@
+package peers
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Just enough of the protocol buffers wire format for the messages in
+// rippled's ripple.proto, so that no generated code is needed
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+type protoWriter []byte
+
+func (w *protoWriter) tag(field, wireType int) {
+	*w = binary.AppendUvarint(*w, uint64(field<<3|wireType))
+}
+
+func (w *protoWriter) varint(field int, v uint64) {
+	w.tag(field, wireVarint)
+	*w = binary.AppendUvarint(*w, v)
+}
+
+func (w *protoWriter) bool(field int, v bool) {
+	if v {
+		w.varint(field, 1)
+	} else {
+		w.varint(field, 0)
+	}
+}
+
+func (w *protoWriter) bytes(field int, b []byte) {
+	w.tag(field, wireBytes)
+	*w = binary.AppendUvarint(*w, uint64(len(b)))
+	*w = append(*w, b...)
+}
+
+func (w *protoWriter) optionalUint32(field int, v *uint32) {
+	if v != nil {
+		w.varint(field, uint64(*v))
+	}
+}
+
+func (w *protoWriter) optionalUint64(field int, v *uint64) {
+	if v != nil {
+		w.varint(field, *v)
+	}
+}
+
+func (w *protoWriter) optionalBool(field int, v *bool) {
+	if v != nil {
+		w.bool(field, *v)
+	}
+}
+
+// A decoded field. Varint and fixed values are in value and length
+// delimited ones in bytes.
+type protoField struct {
+	number   int
+	wireType int
+	value    uint64
+	bytes    []byte
+}
+
+func (f *protoField) uint32() *uint32 {
+	v := uint32(f.value)
+	return &v
+}
+
+func (f *protoField) uint64() *uint64 {
+	v := f.value
+	return &v
+}
+
+func (f *protoField) bool() *bool {
+	v := f.value != 0
+	return &v
+}
+
+func (f *protoField) hash(name string, h []byte) error {
+	if f.wireType != wireBytes || len(f.bytes) != len(h) {
+		return fmt.Errorf("peers: bad %s", name)
+	}
+	copy(h, f.bytes)
+	return nil
+}
+
+// Calls fn with each field of a message in order
+func readProto(b []byte, fn func(*protoField) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("peers: bad protobuf tag")
+		}
+		b = b[n:]
+		f := protoField{number: int(tag >> 3), wireType: int(tag & 7)}
+		switch f.wireType {
+		case wireVarint:
+			if f.value, n = binary.Uvarint(b); n <= 0 {
+				return fmt.Errorf("peers: bad protobuf varint")
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return fmt.Errorf("peers: short protobuf field")
+			}
+			f.value, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return fmt.Errorf("peers: short protobuf field")
+			}
+			f.value, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return fmt.Errorf("peers: short protobuf field")
+			}
+			f.bytes, b = b[n:n+int(length)], b[n+int(length):]
+		default:
+			return fmt.Errorf("peers: unsupported protobuf wire type: %d", f.wireType)
+		}
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
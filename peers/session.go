@@ -0,0 +1,275 @@
+package peers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+)
+
+// rippled proves that each end of a connection holds its node key by
+// signing a value derived from the TLS Finished messages, which OpenSSL
+// exposes and crypto/tls does not. The connection is limited to TLS 1.2
+// without resumption so the server's Finished message can be recomputed
+// from the handshake transcript and the master secret. The client's, which
+// crypto/tls does expose as TLSUnique, checks the result.
+
+const (
+	recordChangeCipherSpec = 20
+	recordHandshake        = 22
+	finishedLength         = 12
+
+	handshakeNewSessionTicket = 4
+)
+
+// Collects the plaintext handshake messages sent in one direction before
+// ChangeCipherSpec
+type transcript struct {
+	buf       []byte
+	handshake []byte
+	done      bool
+}
+
+func (t *transcript) write(b []byte) {
+	if t.done {
+		return
+	}
+	t.buf = append(t.buf, b...)
+	for len(t.buf) >= 5 {
+		length := int(binary.BigEndian.Uint16(t.buf[3:5]))
+		if len(t.buf) < 5+length {
+			return
+		}
+		switch t.buf[0] {
+		case recordHandshake:
+			t.handshake = append(t.handshake, t.buf[5:5+length]...)
+		case recordChangeCipherSpec:
+			t.done = true
+			t.buf = nil
+			return
+		}
+		t.buf = t.buf[5+length:]
+	}
+}
+
+// Records the handshake in both directions
+type recorder struct {
+	net.Conn
+	mu       sync.Mutex
+	read     transcript
+	written  transcript
+	keyLog   bytes.Buffer
+	finished bool
+}
+
+func (r *recorder) Read(b []byte) (int, error) {
+	n, err := r.Conn.Read(b)
+	r.mu.Lock()
+	if !r.finished {
+		r.read.write(b[:n])
+	}
+	r.mu.Unlock()
+	return n, err
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	if !r.finished {
+		r.written.write(b)
+	}
+	r.mu.Unlock()
+	return r.Conn.Write(b)
+}
+
+func (r *recorder) stop() {
+	r.mu.Lock()
+	r.finished = true
+	r.mu.Unlock()
+}
+
+// Overwrites the key log, which holds the session's master secret
+func (r *recorder) forget() {
+	r.keyLog.Reset()
+	b := r.keyLog.Bytes()
+	b = b[:cap(b)]
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func tlsConfig(r *recorder) *tls.Config {
+	return &tls.Config{
+		MinVersion:             tls.VersionTLS12,
+		MaxVersion:             tls.VersionTLS12,
+		SessionTicketsDisabled: true,
+		// Peers use self-signed certificates and are identified by their
+		// node keys instead
+		InsecureSkipVerify: true,
+		KeyLogWriter:       &r.keyLog,
+	}
+}
+
+// Returns the master secret from the NSS key log written by crypto/tls
+func (r *recorder) masterSecret() ([]byte, error) {
+	scanner := bufio.NewScanner(&r.keyLog)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "CLIENT_RANDOM" {
+			return hex.DecodeString(fields[2])
+		}
+	}
+	return nil, fmt.Errorf("peers: no TLS master secret")
+}
+
+// The TLS 1.2 PRF
+func prf(h func() hash.Hash, secret []byte, label string, seed []byte, length int) []byte {
+	seed = append([]byte(label), seed...)
+	mac := hmac.New(h, secret)
+	mac.Write(seed)
+	a := mac.Sum(nil)
+	var out []byte
+	for len(out) < length {
+		mac.Reset()
+		mac.Write(a)
+		mac.Write(seed)
+		out = mac.Sum(out)
+		mac.Reset()
+		mac.Write(a)
+		a = mac.Sum(nil)
+	}
+	return out[:length]
+}
+
+func prfHash(cipherSuite uint16) func() hash.Hash {
+	switch cipherSuite {
+	case tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384:
+		return sha512.New384
+	default:
+		return sha256.New
+	}
+}
+
+func finished(h func() hash.Hash, master []byte, label string, messages ...[]byte) []byte {
+	digest := h()
+	for _, m := range messages {
+		digest.Write(m)
+	}
+	return prf(h, master, label, digest.Sum(nil), finishedLength)
+}
+
+// Splits off the first handshake message
+func firstMessage(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, fmt.Errorf("peers: short TLS handshake")
+	}
+	length := 4 + (int(b[1])<<16 | int(b[2])<<8 | int(b[3]))
+	if len(b) < length {
+		return nil, nil, fmt.Errorf("peers: short TLS handshake")
+	}
+	return b[:length], b[length:], nil
+}
+
+// sharedValue returns what each end of the connection signs, computed as
+// rippled's makeSharedValue does from the Finished messages. It is only
+// tested between two ends of this package: no transcript of a handshake
+// with rippled, or another OpenSSL based peer, has been checked against it.
+func (r *recorder) sharedValue(state tls.ConnectionState, client bool) ([]byte, error) {
+	r.stop()
+	defer r.forget()
+	if state.Version != tls.VersionTLS12 || state.DidResume || len(state.TLSUnique) != finishedLength {
+		return nil, fmt.Errorf("peers: need a full TLS 1.2 handshake")
+	}
+	master, err := r.masterSecret()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range master {
+			master[i] = 0
+		}
+	}()
+	clientMessages, serverMessages := r.written.handshake, r.read.handshake
+	if !client {
+		clientMessages, serverMessages = serverMessages, clientMessages
+	}
+	hello, rest, err := firstMessage(clientMessages)
+	if err != nil {
+		return nil, err
+	}
+	// A NewSessionTicket follows the client's Finished message
+	var server, tickets []byte
+	for len(serverMessages) > 0 {
+		var m []byte
+		if m, serverMessages, err = firstMessage(serverMessages); err != nil {
+			return nil, err
+		}
+		if m[0] == handshakeNewSessionTicket {
+			tickets = append(tickets, m...)
+		} else {
+			server = append(server, m...)
+		}
+	}
+	h := prfHash(state.CipherSuite)
+	clientFinished := finished(h, master, "client finished", hello, server, rest)
+	if !hmac.Equal(clientFinished, state.TLSUnique) {
+		return nil, fmt.Errorf("peers: cannot reproduce the TLS handshake")
+	}
+	header := []byte{20, 0, 0, finishedLength}
+	serverFinished := finished(h, master, "server finished", hello, server, rest, header, clientFinished, tickets)
+	cookie1, cookie2 := sha512.Sum512(clientFinished), sha512.Sum512(serverFinished)
+	var mixed [sha512.Size]byte
+	zero := true
+	for i := range mixed {
+		mixed[i] = cookie1[i] ^ cookie2[i]
+		zero = zero && mixed[i] == 0
+	}
+	if zero {
+		return nil, fmt.Errorf("peers: identical TLS Finished messages")
+	}
+	return crypto.Sha512Half(mixed[:]), nil
+}
+
+// Generates a self-signed certificate, as rippled does
+func selfSignedCertificate() (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "rippled"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
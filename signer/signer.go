@@ -0,0 +1,223 @@
+// Package signer lets transactions be signed by a separate signing daemon
+// so that private keys never enter the process which builds and submits
+// them. The daemon serves Handler over a unix socket or HTTP, and Remote is
+// a crypto.Signer which talks to it.
+//
+// The protocol is JSON over HTTP POST:
+//
+//	/public_key {"key": label} -> {"public_key": hex}
+//	/sign       {"key": label, "hash": hex, "message": hex} -> {"signature": hex, "public_key": hex}
+//
+// Failures have a non-200 status and {"error": message}.
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/rubblelabs/ripple/crypto"
+)
+
+type publicKeyRequest struct {
+	Key string `json:"key"`
+}
+
+type signRequest struct {
+	Key     string `json:"key"`
+	Hash    string `json:"hash"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Remote is a crypto.Signer for one key held by a signing daemon
+type Remote struct {
+	endpoint string
+	key      string
+	client   *http.Client
+
+	mu     sync.Mutex
+	public []byte
+}
+
+var _ crypto.Signer = (*Remote)(nil)
+
+// NewRemote signs with the key labelled key at endpoint, ie.
+// "http://127.0.0.1:8339". A nil client uses http.DefaultClient.
+func NewRemote(endpoint, key string, client *http.Client) *Remote {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Remote{
+		endpoint: endpoint,
+		key:      key,
+		client:   client,
+	}
+}
+
+// NewUnixRemote signs with the key labelled key by a daemon listening on
+// the unix socket at path
+func NewUnixRemote(path, key string) *Remote {
+	var dialer net.Dialer
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	return NewRemote("http://unix", key, client)
+}
+
+func (r *Remote) post(path string, request interface{}) (*response, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Post(r.endpoint+path, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("signer: %s: %s", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		return nil, fmt.Errorf("signer: %s: %s", resp.Status, result.Error)
+	}
+	return &result, nil
+}
+
+// PublicKey is fetched from the daemon once and then remembered
+func (r *Remote) PublicKey() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.public != nil {
+		return r.public, nil
+	}
+	result, err := r.post("/public_key", &publicKeyRequest{Key: r.key})
+	if err != nil {
+		return nil, err
+	}
+	public, err := hex.DecodeString(result.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	r.public = public
+	return public, nil
+}
+
+func (r *Remote) Sign(hash, msg []byte) ([]byte, error) {
+	public, err := r.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.post("/sign", &signRequest{
+		Key:     r.key,
+		Hash:    fmt.Sprintf("%X", hash),
+		Message: fmt.Sprintf("%X", msg),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.PublicKey != fmt.Sprintf("%X", public) {
+		return nil, fmt.Errorf("signer: %s signed with an unexpected key: %s", r.key, result.PublicKey)
+	}
+	return hex.DecodeString(result.Signature)
+}
+
+// Handler serves the signing protocol for a set of labelled signers
+type Handler struct {
+	signers map[string]crypto.Signer
+	// Approve, if set, is called with the message before each signature
+	// and can refuse it by returning an error. The message is the signing
+	// prefix followed by the transaction, and for multisigning the signer's
+	// account.
+	Approve func(key string, msg []byte) error
+}
+
+func NewHandler(signers map[string]crypto.Signer) *Handler {
+	return &Handler{
+		signers: signers,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		h.fail(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", req.Method))
+		return
+	}
+	var request signRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		h.fail(w, http.StatusBadRequest, err)
+		return
+	}
+	signer, ok := h.signers[request.Key]
+	if !ok {
+		h.fail(w, http.StatusNotFound, fmt.Errorf("unknown key: %s", request.Key))
+		return
+	}
+	public, err := signer.PublicKey()
+	if err != nil {
+		h.fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := &response{PublicKey: fmt.Sprintf("%X", public)}
+	switch req.URL.Path {
+	case "/public_key":
+	case "/sign":
+		status, err := h.sign(signer, &request, result)
+		if err != nil {
+			h.fail(w, status, err)
+			return
+		}
+	default:
+		h.fail(w, http.StatusNotFound, fmt.Errorf("unknown path: %s", req.URL.Path))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *Handler) sign(signer crypto.Signer, request *signRequest, result *response) (int, error) {
+	hash, err := hex.DecodeString(request.Hash)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	msg, err := hex.DecodeString(request.Message)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	// Only sign hashes of messages which can be approved
+	if !bytes.Equal(hash, crypto.Sha512Half(msg)) {
+		return http.StatusBadRequest, fmt.Errorf("hash does not match message")
+	}
+	if h.Approve != nil {
+		if err := h.Approve(request.Key, msg); err != nil {
+			return http.StatusForbidden, err
+		}
+	}
+	signature, err := signer.Sign(hash, msg)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	result.Signature = fmt.Sprintf("%X", signature)
+	return http.StatusOK, nil
+}
+
+func (h *Handler) fail(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&response{Error: err.Error()})
+}
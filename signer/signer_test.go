@@ -0,0 +1,115 @@
+package signer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type SignerSuite struct {
+	handler *Handler
+	server  *httptest.Server
+}
+
+var _ = Suite(&SignerSuite{})
+
+func newTestKey(c *C, password string, keyType data.KeyType) crypto.Signer {
+	hash, err := crypto.GenerateFamilySeed(password)
+	c.Assert(err, IsNil)
+	var seed data.Seed
+	copy(seed[:], hash.Payload())
+	if keyType == data.Ed25519 {
+		return crypto.NewKeySigner(seed.Key(keyType), nil)
+	}
+	return crypto.NewKeySigner(seed.Key(keyType), new(uint32))
+}
+
+func (s *SignerSuite) SetUpTest(c *C) {
+	s.handler = NewHandler(map[string]crypto.Signer{
+		"hot":  newTestKey(c, "hot", data.ECDSA),
+		"cold": newTestKey(c, "cold", data.Ed25519),
+	})
+	s.server = httptest.NewServer(s.handler)
+}
+
+func (s *SignerSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func newTestPayment(c *C) *data.Payment {
+	destination, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	amount, err := data.NewAmount("1000000")
+	c.Assert(err, IsNil)
+	fee, err := data.NewNativeValue(12)
+	c.Assert(err, IsNil)
+	return &data.Payment{
+		TxBase: data.TxBase{
+			TransactionType: data.PAYMENT,
+			Sequence:        1,
+			Fee:             *fee,
+		},
+		Destination: *destination,
+		Amount:      *amount,
+	}
+}
+
+func (s *SignerSuite) TestSign(c *C) {
+	for _, key := range []string{"hot", "cold"} {
+		remote := NewRemote(s.server.URL, key, nil)
+		id, err := crypto.SignerId(remote)
+		c.Assert(err, IsNil)
+		tx := newTestPayment(c)
+		copy(tx.Account[:], id)
+		c.Assert(data.SignWith(tx, remote), IsNil)
+		ok, err := data.CheckSignature(tx)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true, Commentf(key))
+
+		// The same signature as signing in process
+		local := newTestPayment(c)
+		copy(local.Account[:], id)
+		c.Assert(data.SignWith(local, s.handler.signers[key]), IsNil)
+		c.Assert(local.GetPublicKey().String(), Equals, tx.GetPublicKey().String())
+	}
+}
+
+func (s *SignerSuite) TestUnixSocket(c *C) {
+	path := filepath.Join(c.MkDir(), "signer.sock")
+	listener, err := net.Listen("unix", path)
+	c.Assert(err, IsNil)
+	server := &http.Server{Handler: s.handler}
+	go server.Serve(listener)
+	defer server.Close()
+
+	remote := NewUnixRemote(path, "hot")
+	tx := newTestPayment(c)
+	c.Assert(data.SignWith(tx, remote), IsNil)
+	ok, err := data.CheckSignature(tx)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *SignerSuite) TestRefused(c *C) {
+	_, err := NewRemote(s.server.URL, "missing", nil).PublicKey()
+	c.Assert(err, ErrorMatches, "signer: 404 Not Found: unknown key: missing")
+
+	remote := NewRemote(s.server.URL, "hot", nil)
+	_, err = remote.Sign(make([]byte, 32), []byte("message"))
+	c.Assert(err, ErrorMatches, ".*hash does not match message")
+
+	s.handler.Approve = func(key string, msg []byte) error {
+		return fmt.Errorf("%s is frozen", key)
+	}
+	err = data.SignWith(newTestPayment(c), remote)
+	c.Assert(err, ErrorMatches, "signer: 403 Forbidden: hot is frozen")
+}
@@ -28,16 +28,23 @@ var (
 	leStyle         = color.New(color.FgWhite)
 	txStyle         = color.New(color.FgGreen)
 	proposalStyle   = color.New(color.FgYellow)
-	validationStyle = color.New(color.FgYellow, color.Bold)
+	ValidationStyle = color.New(color.FgYellow, color.Bold)
 	tradeStyle      = color.New(color.FgBlue)
 	balanceStyle    = color.New(color.FgMagenta)
 	pathStyle       = color.New(color.FgYellow)
 	offerStyle      = color.New(color.FgYellow)
 	lineStyle       = color.New(color.FgYellow)
-	infoStyle       = color.New(color.FgRed)
+	InfoStyle       = color.New(color.FgRed)
 )
 
-func defaultUint32(v *uint32) uint32 {
+func DefaultUint32(v *uint32) uint32 {
+	if v != nil {
+		return *v
+	}
+	return 0
+}
+
+func DefaultUint64(v *uint64) uint64 {
 	if v != nil {
 		return *v
 	}
@@ -122,7 +129,7 @@ func newTxBundle(v data.Transaction, insert string, flag Flag) (*bundle, error)
 		values = append(values, []interface{}{tx.Destination, tx.Amount, tx.SendMax}...)
 	case *data.OfferCreate:
 		format += "%-9d %-60s %-60s %-18s"
-		values = append(values, []interface{}{defaultUint32(tx.OfferSequence), tx.TakerPays, tx.TakerGets, tx.Ratio()}...)
+		values = append(values, []interface{}{DefaultUint32(tx.OfferSequence), tx.TakerPays, tx.TakerGets, tx.Ratio()}...)
 	case *data.OfferCancel:
 		format += "%-9d"
 		values = append(values, tx.OfferSequence)
@@ -154,7 +161,7 @@ func newTxmBundle(txm *data.TransactionWithMetaData, flag Flag) (*bundle, error)
 		// Likely a proposed transaction
 		b.color = proposalStyle
 	} else if !txm.MetaData.TransactionResult.Success() {
-		b.color = infoStyle
+		b.color = InfoStyle
 	}
 	return b, nil
 }
@@ -178,7 +185,7 @@ func newBundle(value interface{}, flag Flag) (*bundle, error) {
 		}, nil
 	case websockets.ServerStreamMsg:
 		return &bundle{
-			color:  infoStyle,
+			color:  InfoStyle,
 			format: "Server Status: %s (%d/%d)",
 			values: []interface{}{v.Status, v.LoadFactor, v.LoadBase},
 			flag:   flag,
@@ -206,7 +213,7 @@ func newBundle(value interface{}, flag Flag) (*bundle, error) {
 		}, nil
 	case data.Validation:
 		return &bundle{
-			color:  validationStyle,
+			color:  ValidationStyle,
 			format: "%s Validation: %s %s %s %-8d %08X %s",
 			values: []interface{}{SignSymbol(&v), v.SigningPubKey.NodePublicKey(), v.SigningTime.String(), v.LedgerHash, v.LedgerSequence, v.Flags, v.Amendments},
 			flag:   flag,
@@ -259,7 +266,7 @@ func newBundle(value interface{}, flag Flag) (*bundle, error) {
 		}, nil
 	default:
 		return &bundle{
-			color:  infoStyle,
+			color:  InfoStyle,
 			format: "%s",
 			values: []interface{}{v},
 			flag:   flag,
@@ -290,7 +297,7 @@ func println(value interface{}, flag Flag) (int, error) {
 
 func Println(value interface{}, flag Flag) {
 	if _, err := println(value, flag); err != nil {
-		infoStyle.Println(err.Error())
+		InfoStyle.Println(err.Error())
 	}
 }
 
@@ -33,6 +33,9 @@ explain 955A4C0B7C66FC97EA4C72634CDCDBF50BB17AAA647EC6C8C592788E5B95173C
 explain -
 	Explain binary transactions received through stdin
 
+explain - -simulate
+	Simulate hex transaction blobs received through stdin and explain their outcome
+
 Options:`
 
 var argumentRegex = regexp.MustCompile(`(^[0-9a-fA-F]{64}$)|(^\d+$)|(^[r][a-km-zA-HJ-NP-Z0-9]{26,34}$)|(-)`)
@@ -45,6 +48,7 @@ var (
 	paths        = flag.Bool("p", false, "hide paths")
 	transactions = flag.Bool("tx", false, "hide transactions")
 	pageSize     = flag.Int("page_size", 20, "page size for account_tx requests")
+	simulate     = flag.Bool("simulate", false, "simulate transaction blobs read from stdin")
 )
 
 func showUsage() {
@@ -123,6 +127,17 @@ func main() {
 		for txm := range r.AccountTx(*account, *pageSize, -1, -1) {
 			explain(txm, terminal.ShowLedgerSequence)
 		}
+	case len(matches[4]) > 0 && *simulate:
+		stdin := bufio.NewReader(os.Stdin)
+		for line, err := stdin.ReadString('\n'); err == nil; line, err = stdin.ReadString('\n') {
+			b, err := hex.DecodeString(line[:len(line)-1])
+			checkErr(err)
+			tx, err := data.ReadTransaction(bytes.NewReader(b))
+			checkErr(err)
+			result, err := r.Simulate(tx)
+			checkErr(err)
+			explain(&result.TransactionWithMetaData, terminal.Default)
+		}
 	case len(matches[4]) > 0:
 		r := bufio.NewReader(os.Stdin)
 		for line, err := r.ReadString('\n'); err == nil; line, err = r.ReadString('\n') {
@@ -0,0 +1,160 @@
+// Tool to manage an encrypted keystore of labelled seeds.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/keystore"
+)
+
+const usage = `Usage: keystore [add|list|export|remove|rotate-password] [options] [label]
+
+Examples:
+
+keystore add -file keys.json payouts
+	Prompt for a seed, as sXXX, RFC1751 words or secret numbers, and add it as
+	payouts, creating keys.json if needed
+
+keystore add -file keys.json -generate -type ed25519 treasury
+	Add a new random ed25519 seed as treasury
+
+keystore list -file keys.json
+	Show the label, key type, account and creation time of each seed
+
+keystore export -file keys.json payouts
+	Print the seed of payouts
+
+keystore rotate-password -file keys.json
+	Encrypt every seed with a new password
+
+The password is read from the terminal, or from ` + keystore.PasswordEnv + ` if it is set.
+
+Options:`
+
+var (
+	flags    = flag.NewFlagSet("keystore", flag.ExitOnError)
+	file     = flags.String("file", "keystore.json", "keystore file")
+	keyType  = flags.String("type", "secp256k1", "add: key type of the seed, secp256k1 or ed25519")
+	generate = flags.Bool("generate", false, "add: generate a new random seed")
+)
+
+func showUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	flags.PrintDefaults()
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+func newPassword() []byte {
+	password, err := keystore.ReadSecret("New password: ")
+	checkErr(err)
+	again, err := keystore.ReadSecret("Repeat password: ")
+	checkErr(err)
+	if !bytes.Equal(password, again) {
+		log.Fatalln("Passwords do not match")
+	}
+	return password
+}
+
+func open() *keystore.Keystore {
+	password, err := keystore.Password("Password: ")
+	checkErr(err)
+	ks, err := keystore.Open(*file, password)
+	checkErr(err)
+	return ks
+}
+
+func label(args []string) string {
+	if len(args) != 1 {
+		showUsage()
+	}
+	return args[0]
+}
+
+func add(args []string) {
+	name := label(args)
+	kt, err := keystore.ParseKeyType(*keyType)
+	checkErr(err)
+	var ks *keystore.Keystore
+	if _, err := os.Stat(*file); os.IsNotExist(err) {
+		password, ok := os.LookupEnv(keystore.PasswordEnv)
+		if !ok {
+			password = string(newPassword())
+		}
+		ks, err = keystore.New([]byte(password))
+		checkErr(err)
+	} else {
+		ks = open()
+	}
+	var seed data.Seed
+	if *generate {
+		_, err = rand.Read(seed[:])
+		checkErr(err)
+	} else {
+		secret, err := keystore.ReadSecret("Seed: ")
+		checkErr(err)
+		s, err := data.ParseSeed(string(secret))
+		checkErr(err)
+		seed = *s
+	}
+	entry, err := ks.Add(name, seed, kt)
+	checkErr(err)
+	checkErr(ks.Save(*file))
+	fmt.Printf("Added %s: %s\n", entry.Label, entry.Account)
+}
+
+func list() {
+	for _, entry := range open().List() {
+		fmt.Printf("%-16s %-8s %-34s %s\n", entry.Label, entry.KeyType, entry.Account, entry.Created.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func export(args []string) {
+	seed, _, err := open().Export(label(args))
+	checkErr(err)
+	fmt.Println(seed)
+}
+
+func remove(args []string) {
+	ks := open()
+	checkErr(ks.Remove(label(args)))
+	checkErr(ks.Save(*file))
+}
+
+func rotatePassword() {
+	ks := open()
+	checkErr(ks.RotatePassword(newPassword()))
+	checkErr(ks.Save(*file))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showUsage()
+	}
+	flags.Parse(os.Args[2:])
+	switch os.Args[1] {
+	case "add":
+		add(flags.Args())
+	case "list":
+		list()
+	case "export":
+		export(flags.Args())
+	case "remove":
+		remove(flags.Args())
+	case "rotate-password":
+		rotatePassword()
+	default:
+		showUsage()
+	}
+}
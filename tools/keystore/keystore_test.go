@@ -0,0 +1,2 @@
+// Empty test file to ensure keystore tool compiles
+package main
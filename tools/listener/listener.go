@@ -0,0 +1,55 @@
+// Tool to connect to a rippled server with the peer protocol and display
+// the traffic.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/peers"
+	"github.com/rubblelabs/ripple/terminal"
+)
+
+func checkErr(err error) {
+	if err != nil {
+		terminal.Println(err.Error(), terminal.Default)
+		os.Exit(1)
+	}
+}
+
+var (
+	host      = flag.String("host", "s1.ripple.com:51235", "peer host:port to connect to")
+	networkID = flag.Uint("network", 0, "network id of the peer")
+	all       = flag.Bool("all", false, "show every message type, not just proposals, validations and transactions")
+)
+
+func main() {
+	flag.Parse()
+	p, err := peers.Dial(*host, &peers.Config{NetworkID: uint32(*networkID)})
+	checkErr(err)
+	defer p.Close()
+	terminal.Println(fmt.Sprint("Connected to ", p), terminal.Default)
+
+	for {
+		m, err := p.ReadMessage()
+		checkErr(err)
+		switch m := m.(type) {
+		case *peers.ProposeSet:
+			terminal.Println(&m.Proposal, terminal.Default)
+		case *peers.Validation:
+			terminal.Println(m.Validation, terminal.Default)
+		case *peers.Transaction:
+			terminal.Println(m.Transaction, terminal.Indent)
+		case *peers.Manifests:
+			for _, manifest := range m.Manifests {
+				terminal.Println(fmt.Sprintf("Manifest: %s %d %s", manifest.PublicKey.NodePublicKey(), manifest.Sequence, terminal.BoolSymbol(data.CheckManifest(manifest) == nil)), terminal.Indent)
+			}
+		default:
+			if *all {
+				terminal.Println(m.Type().String(), terminal.Indent)
+			}
+		}
+	}
+}
@@ -0,0 +1,2 @@
+// Empty test file to ensure listener tool compiles
+package main
@@ -0,0 +1,193 @@
+// Tool to collect the signatures of a SignerList offline and combine them into a submittable transaction.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/rubblelabs/ripple/config"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/multisign"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+const usage = `Usage: multisign [export|sign|combine] [options] [files]
+
+Examples:
+
+multisign export < tx.json > unsigned.json
+	Export a JSON transaction for signing. With -online, a missing Sequence and
+	Fee are filled in from the host allowing for every member of the SignerList.
+
+multisign sign -seed sXXX unsigned.json > alice.json
+	Review and sign an exported transaction as the account of the seed, or as
+	-account when the seed is that account's regular key
+
+multisign combine unsigned.json alice.json bob.json
+	Verify the partial signatures against the SignerList on the host and print
+	the combined tx_blob, optionally submitting it
+
+Options:`
+
+var (
+	flags   = flag.NewFlagSet("multisign", flag.ExitOnError)
+	host    = flags.String("host", "wss://s2.ripple.com:443", "websockets host")
+	online  = flags.Bool("online", false, "export: fill in Sequence and Fee from the host")
+	seed    = flags.String("seed", "", "sign: seed of the signing key")
+	ed25519 = flags.Bool("ed25519", false, "sign: the seed is for an ed25519 key")
+	account = flags.String("account", "", "sign: the signer account, if not the account of the seed")
+	submit  = flags.Bool("submit", false, "combine: submit the combined transaction")
+)
+
+func showUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	flags.PrintDefaults()
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+func writeJSON(v interface{}) {
+	out, err := json.MarshalIndent(v, "", "\t")
+	checkErr(err)
+	fmt.Println(string(out))
+}
+
+func readFile(name string, read func(io.Reader) error) {
+	f, err := os.Open(name)
+	checkErr(err)
+	defer f.Close()
+	checkErr(read(f))
+}
+
+func readUnsigned(name string) *multisign.Unsigned {
+	var u *multisign.Unsigned
+	readFile(name, func(r io.Reader) (err error) {
+		u, err = multisign.ReadUnsigned(r)
+		return
+	})
+	return u
+}
+
+func export() {
+	b, err := io.ReadAll(os.Stdin)
+	checkErr(err)
+	tx, err := config.ParseTransaction(b)
+	checkErr(err)
+	base := tx.GetBase()
+	if *online && (base.Sequence == 0 || base.Fee.IsZero()) {
+		remote, err := websockets.NewRemote(*host)
+		checkErr(err)
+		defer remote.Close()
+		if base.Sequence == 0 {
+			info, err := remote.AccountInfo(base.Account)
+			checkErr(err)
+			base.Sequence = *info.AccountData.Sequence
+		}
+		if base.Fee.IsZero() {
+			signers, err := multisign.FetchSigners(remote, base.Account)
+			checkErr(err)
+			fee, err := remote.Fee()
+			checkErr(err)
+			// Each signature costs a base fee on top of the usual one
+			multiplier, err := data.NewNonNativeValue(int64(len(signers.Weights)+1), 0)
+			checkErr(err)
+			total, err := fee.Suggested().Multiply(*multiplier)
+			checkErr(err)
+			base.Fee = *total
+		}
+	}
+	u, err := multisign.Export(tx)
+	checkErr(err)
+	writeJSON(u)
+}
+
+func sign(files []string) {
+	if len(files) != 1 || *seed == "" {
+		showUsage()
+	}
+	u := readUnsigned(files[0])
+	s, err := data.NewSeedFromAddress(*seed)
+	checkErr(err)
+	var (
+		keyType  = data.ECDSA
+		sequence *uint32
+	)
+	if *ed25519 {
+		keyType = data.Ed25519
+	} else {
+		sequence = new(uint32)
+	}
+	signer := s.AccountId(keyType, sequence)
+	if *account != "" {
+		a, err := data.NewAccountFromAddress(*account)
+		checkErr(err)
+		signer = *a
+	}
+	review, err := json.MarshalIndent(u.TxJson, "", "\t")
+	checkErr(err)
+	fmt.Fprintf(os.Stderr, "Signing %s as %s:\n%s\n", u.Hash, signer, review)
+	partial, err := u.Sign(s.Key(keyType), sequence, signer)
+	checkErr(err)
+	writeJSON(partial)
+}
+
+func combine(files []string) {
+	if len(files) < 2 {
+		showUsage()
+	}
+	u := readUnsigned(files[0])
+	var partials []*multisign.Partial
+	for _, name := range files[1:] {
+		readFile(name, func(r io.Reader) error {
+			partial, err := multisign.ReadPartial(r)
+			partials = append(partials, partial)
+			return err
+		})
+	}
+	remote, err := websockets.NewRemote(*host)
+	checkErr(err)
+	defer remote.Close()
+	signers, err := multisign.FetchSigners(remote, u.TxJson.GetBase().Account)
+	checkErr(err)
+	tx, err := signers.Combine(u, partials...)
+	if err != nil {
+		for _, missing := range signers.Missing(partials...) {
+			fmt.Fprintf(os.Stderr, "Not signed by %s (weight %d)\n", missing, signers.Weights[missing])
+		}
+		checkErr(err)
+	}
+	blob, err := multisign.TxBlob(tx)
+	checkErr(err)
+	fmt.Println(blob)
+	if *submit {
+		result, err := remote.Submit(tx)
+		checkErr(err)
+		log.Printf("%s: %s %s", tx.GetHash(), result.EngineResult, result.EngineResultMessage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showUsage()
+	}
+	flags.Parse(os.Args[2:])
+	switch os.Args[1] {
+	case "export":
+		export()
+	case "sign":
+		sign(flags.Args())
+	case "combine":
+		combine(flags.Args())
+	default:
+		showUsage()
+	}
+}
@@ -0,0 +1,2 @@
+// Empty test file to ensure multisign tool compiles
+package main
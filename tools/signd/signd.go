@@ -0,0 +1,102 @@
+// Reference signing daemon which holds keys so that the processes submitting transactions do not have to.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/keystore"
+	"github.com/rubblelabs/ripple/signer"
+)
+
+const usage = `Usage: signd -keystore keys.json [-socket path|-listen address]
+
+Every seed in the keystore, as managed by the keystore tool, can be used by
+its label. The password is read from the terminal, or from ` + keystore.PasswordEnv + `.
+
+Clients sign with signer.NewUnixRemote(path, label) or signer.NewRemote(url, label, nil).
+There is no authentication, so only listen on a unix socket or a loopback address.
+The socket's directory, such as ~/.signd, is created if needed and must only be
+accessible by its owner.
+
+Options:`
+
+var (
+	file   = flag.String("keystore", "", "keystore file")
+	socket = flag.String("socket", "", "unix socket to listen on")
+	listen = flag.String("listen", "", "address to listen on, ie. 127.0.0.1:8339")
+)
+
+func showUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	flag.PrintDefaults()
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+func readKeys(path string) map[string]crypto.Signer {
+	password, err := keystore.Password("Password: ")
+	checkErr(err)
+	ks, err := keystore.Open(path, password)
+	checkErr(err)
+	signers := make(map[string]crypto.Signer)
+	for _, entry := range ks.List() {
+		signers[entry.Label], err = ks.Signer(entry.Label)
+		checkErr(err)
+		log.Printf("Loaded %s: %s", entry.Label, entry.Account)
+	}
+	return signers
+}
+
+// Anyone who can connect to the socket can have messages signed, so it is
+// only created in a directory which no one else can enter
+func listenUnix(path string) net.Listener {
+	dir := filepath.Dir(path)
+	checkErr(os.MkdirAll(dir, 0700))
+	info, err := os.Stat(dir)
+	checkErr(err)
+	// Windows controls access with ACLs rather than permission bits
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		log.Fatalf("%s must only be accessible by its owner, ie. chmod 700 %s", dir, dir)
+	}
+	listener, err := net.Listen("unix", path)
+	checkErr(err)
+	checkErr(os.Chmod(path, 0600))
+	return listener
+}
+
+func main() {
+	flag.Parse()
+	if *file == "" || (*socket == "") == (*listen == "") {
+		showUsage()
+	}
+	handler := signer.NewHandler(readKeys(*file))
+	handler.Approve = func(key string, msg []byte) error {
+		log.Printf("Signing %d bytes with %s", len(msg), key)
+		return nil
+	}
+	var (
+		listener net.Listener
+		err      error
+	)
+	if *socket != "" {
+		listener = listenUnix(*socket)
+	} else {
+		listener, err = net.Listen("tcp", *listen)
+		checkErr(err)
+	}
+	log.Printf("Listening on %s", listener.Addr())
+	checkErr(http.Serve(listener, handler))
+}
@@ -0,0 +1,2 @@
+// Empty test file to ensure signd tool compiles
+package main
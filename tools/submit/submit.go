@@ -1,15 +1,32 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/rubblelabs/ripple/config"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/keystore"
+	"github.com/rubblelabs/ripple/websockets"
 )
 
 var (
-	host = flag.String("host", "wss://s2.ripple.com:443", "websockets host")
+	host     = flag.String("host", "wss://s2.ripple.com:443", "websockets host")
+	online   = flag.Bool("online", false, "fill in sequences, fees and LastLedgerSequence from the host")
+	ledgers  = flag.Uint("ledgers", 20, "ledgers after the current ledger to set LastLedgerSequence when online")
+	dryRun   = flag.Bool("dry-run", false, "prepare, sign and preflight the transactions and print the plan without submitting")
+	simulate = flag.Bool("simulate", false, "simulate each planned transaction against the open ledger and show balance changes")
+	out      = flag.String("out", "", "write the signed tx_blobs to this file, one per line")
+	blobs    = flag.String("blobs", "", "submit the signed tx_blobs in this file instead of reading actions from stdin")
+	accounts = flag.String("accounts", "", "reconcile accounts with the desired state in this file instead of reading actions from stdin")
+	yes      = flag.Bool("yes", false, "apply reconciled changes without asking for confirmation")
+	keys     = flag.String("keystore", "", "keystore holding the seeds of actions and accounts which give a key label")
 )
 
 func checkErr(err error) {
@@ -18,11 +35,160 @@ func checkErr(err error) {
 	}
 }
 
+func openKeystore() *keystore.Keystore {
+	if *keys == "" {
+		return nil
+	}
+	password, err := keystore.Password("Keystore password: ")
+	checkErr(err)
+	ks, err := keystore.Open(*keys, password)
+	checkErr(err)
+	return ks
+}
+
 func main() {
 	flag.Parse()
-	actions, err := config.Parse(os.Stdin)
+	if *blobs != "" {
+		submitBlobs(*blobs)
+		return
+	}
+	var (
+		actions config.ActionSlice
+		remote  *websockets.Remote
+		err     error
+	)
+	if *online || *simulate || !*dryRun || *accounts != "" {
+		remote, err = websockets.NewRemote(*host)
+		checkErr(err)
+	}
+	if *accounts != "" {
+		actions = reconcile(remote, *accounts, openKeystore())
+		if len(actions) == 0 {
+			log.Println("All accounts are in the desired state")
+			return
+		}
+		// Reconciled transactions have no sequences
+		*online = true
+	} else {
+		actions, err = config.Parse(os.Stdin)
+		checkErr(err)
+		if ks := openKeystore(); ks != nil {
+			checkErr(actions.UseKeystore(ks))
+		}
+	}
+	if *online {
+		checkErr(actions.PrepareOnline(remote, uint32(*ledgers)))
+	} else {
+		checkErr(actions.Prepare())
+	}
+	plan, err := actions.Plan()
 	checkErr(err)
-	checkErr(actions.Prepare())
-	checkErr(actions.Submit(*host))
+	if *out != "" {
+		f, err := os.Create(*out)
+		checkErr(err)
+		checkErr(config.WriteBlobs(f, plan))
+		checkErr(f.Close())
+		log.Printf("Wrote %d signed transactions to %s", len(plan), *out)
+	}
+	if *dryRun {
+		for _, entry := range plan {
+			fmt.Println(entry)
+			fmt.Println(entry.TxBlob)
+			if *simulate {
+				explain(remote, entry.Transaction)
+			}
+		}
+		log.Printf("Planned %d transactions", len(plan))
+		return
+	}
+	if *accounts != "" && !*yes && !confirm(len(plan)) {
+		return
+	}
+	checkErr(actions.SubmitTo(remote))
 	log.Printf("Submitted %d transactions", actions.Count())
 }
+
+// Prints the differences between each account and its desired state and
+// returns the actions which resolve them
+func reconcile(remote *websockets.Remote, path string, ks *keystore.Keystore) config.ActionSlice {
+	f, err := os.Open(path)
+	checkErr(err)
+	defer f.Close()
+	states, err := config.ParseAccounts(f)
+	checkErr(err)
+	var actions config.ActionSlice
+	for i := range states {
+		if ks != nil {
+			checkErr(states[i].UseKeystore(ks))
+		}
+		r, err := states[i].Reconcile(remote)
+		checkErr(err)
+		if len(r.Differences) == 0 {
+			fmt.Printf("%s: no changes\n", r.Account)
+			continue
+		}
+		fmt.Printf("%s:\n", r.Account)
+		for _, diff := range r.Differences {
+			fmt.Printf("\t%s\n", diff)
+		}
+		if len(r.Action.Transactions) > 0 {
+			actions = append(actions, r.Action)
+		}
+	}
+	return actions
+}
+
+func confirm(count int) bool {
+	fmt.Printf("Apply %d transactions? [y/N] ", count)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
+
+// Each transaction is simulated on its own against the open ledger, so
+// those which depend on earlier planned transactions, ie. later sequences,
+// will report terPRE_SEQ.
+func explain(remote *websockets.Remote, tx data.Transaction) {
+	result, err := remote.Simulate(tx)
+	if err != nil {
+		fmt.Printf("\tSimulate failed: %s\n", err)
+		return
+	}
+	fmt.Printf("\t%s: %s\n", result.EngineResult, result.EngineResultMessage)
+	balanceMap, err := result.TransactionWithMetaData.Balances()
+	checkErr(err)
+	for account, balances := range balanceMap {
+		fmt.Printf("\t%s\n", account)
+		for _, balance := range *balances {
+			fmt.Printf("\t\t%s\n", balance)
+		}
+	}
+}
+
+func submitBlobs(path string) {
+	f, err := os.Open(path)
+	checkErr(err)
+	defer f.Close()
+	remote, err := websockets.NewRemote(*host)
+	checkErr(err)
+	var count int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		b, err := hex.DecodeString(line)
+		checkErr(err)
+		tx, err := data.ReadTransaction(bytes.NewReader(b))
+		checkErr(err)
+		result, err := remote.Submit(tx)
+		checkErr(err)
+		if !result.EngineResult.Success() {
+			log.Fatalf("%s\n%s", result.EngineResultMessage, line)
+		}
+		count++
+	}
+	checkErr(scanner.Err())
+	log.Printf("Submitted %d transactions", count)
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rubblelabs/ripple/data"
 	"github.com/rubblelabs/ripple/terminal"
@@ -20,8 +21,10 @@ func checkErr(err error, quit bool) {
 }
 
 var (
-	host     = flag.String("host", "wss://s2.ripple.com:443", "websockets host to connect to")
-	proposed = flag.Bool("proposed", false, "include proposed transacions")
+	host        = flag.String("host", "wss://s2.ripple.com:443", "websockets host to connect to")
+	proposed    = flag.Bool("proposed", false, "include proposed transacions")
+	validations = flag.Bool("validations", false, "include validations")
+	accounts    = flag.String("accounts", "", "comma separated accounts to follow instead of all transactions")
 )
 
 func main() {
@@ -29,7 +32,28 @@ func main() {
 	r, err := websockets.NewRemote(*host)
 	checkErr(err, true)
 
-	confirmation, err := r.Subscribe(true, !*proposed, *proposed, true)
+	req := websockets.SubscribeRequest{
+		Streams: []string{websockets.StreamLedger, websockets.StreamServer},
+	}
+	if *validations {
+		req.Streams = append(req.Streams, websockets.StreamValidations)
+	}
+	if len(*accounts) > 0 {
+		for _, address := range strings.Split(*accounts, ",") {
+			account, err := data.NewAccountFromAddress(address)
+			checkErr(err, true)
+			if *proposed {
+				req.AccountsProposed = append(req.AccountsProposed, *account)
+			} else {
+				req.Accounts = append(req.Accounts, *account)
+			}
+		}
+	} else if *proposed {
+		req.Streams = append(req.Streams, websockets.StreamTransactionsProposed)
+	} else {
+		req.Streams = append(req.Streams, websockets.StreamTransactions)
+	}
+	confirmation, err := r.SubscribeTo(req)
 	checkErr(err, true)
 	terminal.Println(fmt.Sprint("Subscribed at: ", confirmation.LedgerSequence), terminal.Default)
 
@@ -60,6 +84,12 @@ func main() {
 			}
 		case *websockets.ServerStreamMsg:
 			terminal.Println(msg, terminal.Default)
+		case *websockets.ValidationStreamMsg:
+			validation, err := msg.Validation()
+			checkErr(err, false)
+			if err == nil {
+				terminal.Println(validation, terminal.Default)
+			}
 		}
 	}
 }
@@ -0,0 +1,226 @@
+// Tool to publish, serve and verify a signed validator list (UNL).
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/validator"
+)
+
+const usage = `Usage: unl [publish|serve|verify] [options] [file or URL]
+
+Examples:
+
+unl publish -token publisher-token.txt -validators validators.txt
+	Sign a version 1 list of the base64 manifests in validators.txt, one per
+	line, which expires in six months and write it to vl.json. The token is a
+	[validator_token] from the validator tool's create_token.
+
+unl publish -version 2 -effective 2026-12-01T00:00:00Z -token publisher-token.txt -validators next.txt
+	Add a list which takes effect on December 1st to the version 2 lists in
+	vl.json, dropping any which have expired
+
+unl serve -listen localhost:8080
+	Serve vl.json to rippled's [validator_list_sites]. The file is read for
+	each request so it can be republished while serving.
+
+unl verify -key ED2677... http://localhost:8080/
+	Check a list against the publisher's key from [validator_list_keys] and
+	show the list in effect
+
+Options:`
+
+var (
+	flags      = flag.NewFlagSet("unl", flag.ExitOnError)
+	file       = flags.String("file", "vl.json", "list file")
+	tokenFile  = flags.String("token", "", "publish: publisher's validator token file")
+	validators = flags.String("validators", "", "publish: file of validator manifests in base64")
+	version    = flags.Int("version", 1, "publish: list version, 1 or 2")
+	sequence   = flags.Uint("sequence", 0, "publish: list sequence, defaults to one more than the last")
+	effective  = flags.String("effective", "", "publish: RFC3339 time the list takes effect, defaults to now")
+	expires    = flags.Duration("expires", 4380*time.Hour, "publish: time after taking effect that the list expires")
+	listen     = flags.String("listen", "localhost:8080", "serve: address to listen on")
+	key        = flags.String("key", "", "verify: publisher's master key in hex")
+)
+
+func showUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	flags.PrintDefaults()
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+func readFile(name string) string {
+	b, err := os.ReadFile(name)
+	checkErr(err)
+	return string(b)
+}
+
+func decode(r io.Reader) *validator.UNL {
+	var l validator.UNL
+	checkErr(json.NewDecoder(r).Decode(&l))
+	return &l
+}
+
+func readManifests() []*data.Manifest {
+	f, err := os.Open(*validators)
+	checkErr(err)
+	defer f.Close()
+	var manifests []*data.Manifest
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m, err := validator.DecodeManifest(line)
+		checkErr(err)
+		manifests = append(manifests, m)
+	}
+	checkErr(scanner.Err())
+	return manifests
+}
+
+func publish() {
+	if *tokenFile == "" || *validators == "" {
+		showUsage()
+	}
+	token, err := validator.ParseToken(readFile(*tokenFile))
+	checkErr(err)
+	publisher, err := validator.NewPublisher(token)
+	checkErr(err)
+	start, from := time.Time{}, time.Now()
+	if *effective != "" {
+		start, err = time.Parse(time.RFC3339, *effective)
+		checkErr(err)
+		from = start
+	}
+	// Keep the unexpired lists of an earlier version 2 file
+	var blobs []*validator.Blob
+	var last uint32
+	if f, err := os.Open(*file); err == nil {
+		previous, err := decode(f).Verify(publisher.PublicKey())
+		f.Close()
+		checkErr(err)
+		for _, b := range previous {
+			if b.Sequence > last {
+				last = b.Sequence
+			}
+			if *version == 2 && b.ExpirationTime().After(time.Now()) {
+				blobs = append(blobs, b)
+			}
+		}
+	}
+	seq := uint32(*sequence)
+	if seq == 0 {
+		seq = last + 1
+	}
+	if seq <= last {
+		log.Fatalf("Sequence %d is not after %d", seq, last)
+	}
+	blob, err := validator.NewBlob(seq, start, from.Add(*expires), readManifests()...)
+	checkErr(err)
+	l, err := publisher.UNL(*version, append(blobs, blob)...)
+	checkErr(err)
+	save(l)
+	fmt.Printf("Published list %d of %d validators, expiring %s, to %s\n", seq, len(blob.Validators), blob.ExpirationTime().UTC().Format(time.RFC3339), *file)
+	fmt.Printf("Publisher key: %s\n", publisher.PublicKey())
+}
+
+// Replaces the list file so that it is never served half written
+func save(l *validator.UNL) {
+	f, err := os.CreateTemp(filepath.Dir(*file), ".vl")
+	checkErr(err)
+	defer os.Remove(f.Name())
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	checkErr(enc.Encode(l))
+	checkErr(f.Close())
+	checkErr(os.Chmod(f.Name(), 0644))
+	checkErr(os.Rename(f.Name(), *file))
+}
+
+func serve() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		b, err := os.ReadFile(*file)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "list unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		log.Printf("%s %s %s", r.RemoteAddr, r.Method, r.URL)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+	log.Printf("Serving %s on %s", *file, *listen)
+	checkErr(http.ListenAndServe(*listen, nil))
+}
+
+func verify(args []string) {
+	if *key == "" || len(args) > 1 {
+		showUsage()
+	}
+	source := *file
+	if len(args) == 1 {
+		source = args[0]
+	}
+	var l *validator.UNL
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		checkErr(err)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Fatalf("%s: %s", source, resp.Status)
+		}
+		l = decode(resp.Body)
+	} else {
+		f, err := os.Open(source)
+		checkErr(err)
+		defer f.Close()
+		l = decode(f)
+	}
+	blobs, err := l.Verify(*key)
+	checkErr(err)
+	for _, b := range blobs {
+		fmt.Printf("List %d: %d validators, effective %s, expires %s\n", b.Sequence, len(b.Validators), b.EffectiveTime().UTC().Format(time.RFC3339), b.ExpirationTime().UTC().Format(time.RFC3339))
+	}
+	current, err := validator.Current(blobs, time.Now())
+	checkErr(err)
+	fmt.Printf("In effect: list %d\n", current.Sequence)
+	for _, v := range current.Validators {
+		fmt.Println(v.PublicKey)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showUsage()
+	}
+	flags.Parse(os.Args[2:])
+	switch os.Args[1] {
+	case "publish":
+		publish()
+	case "serve":
+		serve()
+	case "verify":
+		verify(flags.Args())
+	default:
+		showUsage()
+	}
+}
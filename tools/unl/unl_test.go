@@ -0,0 +1,2 @@
+// Empty test file to ensure unl tool compiles
+package main
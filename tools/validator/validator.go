@@ -0,0 +1,189 @@
+// Tool to manage validator keys and tokens, like rippled's validator-keys.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/validator"
+)
+
+const usage = `Usage: validator [create_keys|create_token|revoke_keys|set_domain|show_manifest] [options] [arg]
+
+Examples:
+
+validator create_keys -keyfile validator-keys.json
+	Generate a new ed25519 master key. Keep the file offline.
+
+validator create_token -keyfile validator-keys.json
+	Generate a new ephemeral key and print a [validator_token] stanza for
+	rippled.cfg which replaces any earlier token
+
+validator set_domain -keyfile validator-keys.json example.com
+	Include a domain in future manifests and print a new token for it
+
+validator revoke_keys -keyfile validator-keys.json
+	Permanently revoke the master key and print the revocation manifest
+
+validator show_manifest < rippled.cfg
+	Check and print the manifest of a token, or a base64 manifest, read from
+	the argument or stdin
+
+Options:`
+
+var (
+	flags   = flag.NewFlagSet("validator", flag.ExitOnError)
+	keyfile = flags.String("keyfile", "validator-keys.json", "validator key file")
+)
+
+func showUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	flags.PrintDefaults()
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+}
+
+func read() *validator.Keys {
+	f, err := os.Open(*keyfile)
+	checkErr(err)
+	defer f.Close()
+	keys, err := validator.ReadKeys(f)
+	checkErr(err)
+	return keys
+}
+
+// Replaces the key file, which is only readable by its owner
+func save(keys *validator.Keys) {
+	f, err := os.CreateTemp(filepath.Dir(*keyfile), ".validator-keys")
+	checkErr(err)
+	defer os.Remove(f.Name())
+	checkErr(keys.Write(f))
+	checkErr(f.Close())
+	checkErr(os.Rename(f.Name(), *keyfile))
+}
+
+func createKeys() {
+	if _, err := os.Stat(*keyfile); err == nil {
+		log.Fatalf("%s already exists", *keyfile)
+	}
+	keys, err := validator.NewKeys()
+	checkErr(err)
+	save(keys)
+	fmt.Printf("Validator public key: %s\nKeys saved to %s\n", keys.PublicKey, *keyfile)
+}
+
+func createToken(keys *validator.Keys) {
+	token, err := keys.CreateToken()
+	checkErr(err)
+	save(keys)
+	fmt.Printf("Update rippled.cfg with this token:\n\n%s\n", token.Config())
+}
+
+func setDomain(args []string) {
+	if len(args) != 1 {
+		showUsage()
+	}
+	keys := read()
+	keys.Domain = args[0]
+	createToken(keys)
+}
+
+func revokeKeys() {
+	keys := read()
+	m, err := keys.Revoke()
+	checkErr(err)
+	manifest, err := validator.EncodeManifest(m)
+	checkErr(err)
+	save(keys)
+	fmt.Printf("Publish this revocation manifest:\n\n%s\n", manifest)
+}
+
+func showManifest(args []string) {
+	var s string
+	switch len(args) {
+	case 0:
+		var lines []string
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		checkErr(scanner.Err())
+		s = strings.Join(lines, "\n")
+	case 1:
+		s = args[0]
+	default:
+		showUsage()
+	}
+	manifest, err := findManifest(s)
+	checkErr(err)
+	m, err := validator.DecodeManifest(manifest)
+	checkErr(err)
+	master, err := crypto.NewNodePublicKey(m.PublicKey.Bytes())
+	checkErr(err)
+	fmt.Printf("Manifest:    %s\n", manifest)
+	fmt.Printf("Hash:        %s\n", m.Hash)
+	fmt.Printf("Master key:  %s\n", master)
+	if m.Revoked() {
+		fmt.Println("Revoked")
+		return
+	}
+	ephemeral, err := crypto.NewNodePublicKey(m.SigningPubKey.Bytes())
+	checkErr(err)
+	fmt.Printf("Signing key: %s\n", ephemeral)
+	fmt.Printf("Sequence:    %d\n", m.Sequence)
+	if m.Domain != nil {
+		fmt.Printf("Domain:      %s\n", string(*m.Domain))
+	}
+}
+
+// Returns the manifest of a token, which may be in a rippled.cfg, after
+// checking its key, or s itself if it is not a token
+func findManifest(s string) (string, error) {
+	// A rippled.cfg holds the token after its stanza header
+	if i := strings.Index(s, "[validator_token]"); i >= 0 {
+		s = s[i:]
+		if j := strings.Index(s[1:], "\n["); j >= 0 {
+			s = s[:j+1]
+		}
+	}
+	token, err := validator.ParseToken(s)
+	if err != nil || token.Manifest == "" {
+		return strings.TrimSpace(s), nil
+	}
+	if _, err := token.Key(); err != nil {
+		return "", err
+	}
+	return token.Manifest, nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		showUsage()
+	}
+	flags.Parse(os.Args[2:])
+	switch os.Args[1] {
+	case "create_keys":
+		createKeys()
+	case "create_token":
+		createToken(read())
+	case "set_domain":
+		setDomain(flags.Args())
+	case "revoke_keys":
+		revokeKeys()
+	case "show_manifest":
+		showManifest(flags.Args())
+	default:
+		showUsage()
+	}
+}
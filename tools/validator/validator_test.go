@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/rubblelabs/ripple/validator"
+)
+
+func TestFindManifest(t *testing.T) {
+	keys, err := validator.NewKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := keys.CreateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := "[server]\nport_rpc\n\n" + token.Config() + "\n\n[validators_file]\nvalidators.txt\n"
+	for _, s := range []string{config, token.String(), token.Manifest, "\n" + token.Manifest + "\n"} {
+		manifest, err := findManifest(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifest != token.Manifest {
+			t.Errorf("manifest: %s expected: %s", manifest, token.Manifest)
+		}
+	}
+
+	// The token's secret must be the manifest's ephemeral key
+	other, err := keys.CreateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.ValidationSecretKey = other.ValidationSecretKey
+	if _, err := findManifest(token.Config()); err == nil {
+		t.Error("expected an error for a mismatched token")
+	}
+}
@@ -0,0 +1,144 @@
+// Tool to monitor how well validators agree with the validated ledgers.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/terminal"
+	"github.com/rubblelabs/ripple/validator"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func checkErr(err error, quit bool) {
+	if err != nil {
+		terminal.Println(err.Error(), terminal.Default)
+		if quit {
+			os.Exit(1)
+		}
+	}
+}
+
+var (
+	host     = flag.String("host", "wss://s2.ripple.com:443", "websockets host to connect to")
+	interval = flag.Duration("interval", 5*time.Second, "time between updates")
+	export   = flag.Bool("json", false, "print the stats as a line of JSON at each update instead of a live view")
+)
+
+// One line of JSON output
+type report struct {
+	Time       time.Time         `json:"time"`
+	Ledger     uint32            `json:"ledger"`
+	Validators []validator.Stats `json:"validators"`
+	Amendments map[string]int    `json:"amendments"`
+}
+
+func amendments(monitor *validator.Monitor) ([]data.Hash256, map[data.Hash256]int) {
+	votes := monitor.AmendmentVotes()
+	hashes := make([]data.Hash256, 0, len(votes))
+	for hash := range votes {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return votes[hashes[i]] > votes[hashes[j]] })
+	return hashes, votes
+}
+
+func row(s validator.Stats) string {
+	return fmt.Sprintf("%s %-52s %-9d %6.2f%% %7d %7d %7d %6dms %3d %d/%d/%d",
+		terminal.BoolSymbol(s.InSync), s.Validator, s.LastSequence, s.Agreement()*100, s.Agreed, s.Disagreed, s.Missed,
+		s.LatencyMillis, len(s.Amendments), terminal.DefaultUint64(s.BaseFee), terminal.DefaultUint32(s.ReserveBase), terminal.DefaultUint32(s.ReserveIncrement))
+}
+
+func show(monitor *validator.Monitor, ledger uint32) {
+	stats := monitor.Stats()
+	// Clear the screen
+	fmt.Print("\033[H\033[2J")
+	terminal.Println(fmt.Sprintf("%s: ledger %d, %d validators", *host, ledger, len(stats)), terminal.Default)
+	fmt.Printf("  %-52s %-9s %7s %7s %7s %7s %8s %3s %s\n", "Validator", "Last", "Agree", "Agreed", "Differ", "Missed", "Latency", "Amd", "Fees")
+	for _, s := range stats {
+		style := terminal.ValidationStyle
+		if !s.InSync {
+			style = terminal.InfoStyle
+		}
+		style.Println(row(s))
+	}
+	hashes, votes := amendments(monitor)
+	if len(hashes) > 0 {
+		fmt.Println("\nAmendment votes:")
+		for _, hash := range hashes {
+			fmt.Printf("    %s %d\n", hash, votes[hash])
+		}
+	}
+}
+
+func write(monitor *validator.Monitor, ledger uint32) {
+	r := report{
+		Time:       time.Now().UTC(),
+		Ledger:     ledger,
+		Validators: monitor.Stats(),
+		Amendments: make(map[string]int),
+	}
+	_, votes := amendments(monitor)
+	for hash, count := range votes {
+		r.Amendments[hash.String()] = count
+	}
+	checkErr(json.NewEncoder(os.Stdout).Encode(r), true)
+}
+
+func main() {
+	flag.Parse()
+	r, err := websockets.NewRemote(*host)
+	checkErr(err, true)
+
+	monitor := validator.NewMonitor()
+	ledgers := make(chan uint32, 1)
+	r.OnLedgerClosed(func(msg *websockets.LedgerStreamMsg) {
+		monitor.LedgerValidated(msg.LedgerSequence, msg.LedgerHash, msg.LedgerTime.Time())
+		select {
+		case <-ledgers:
+		default:
+		}
+		ledgers <- msg.LedgerSequence
+	})
+	r.OnValidation(func(msg *websockets.ValidationStreamMsg) {
+		received := time.Now()
+		v, err := msg.Validation()
+		if err != nil {
+			return
+		}
+		if valid, err := data.CheckSignature(v); !valid || err != nil {
+			return
+		}
+		monitor.AddValidation(msg.MasterKey, v, received)
+	})
+	closed := make(chan struct{})
+	r.OnClose(func() { close(closed) })
+
+	_, err = r.SubscribeTo(websockets.SubscribeRequest{
+		Streams: []string{websockets.StreamLedger, websockets.StreamValidations},
+	})
+	checkErr(err, true)
+
+	var ledger uint32
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ledger = <-ledgers:
+			continue
+		case <-closed:
+			checkErr(fmt.Errorf("Connection to %s closed", *host), true)
+		case <-ticker.C:
+		}
+		if *export {
+			write(monitor, ledger)
+		} else {
+			show(monitor, ledger)
+		}
+	}
+}
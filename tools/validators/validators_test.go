@@ -0,0 +1,2 @@
+// Empty test file to ensure validators tool compiles
+package main
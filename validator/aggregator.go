@@ -0,0 +1,240 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// The fraction of the UNL, less any validators on the NegativeUNL, which
+// must validate a ledger
+const DefaultQuorum = 0.8
+
+// The NegativeUNL never lowers quorum below this fraction of the whole UNL
+const minimumQuorum = 0.6
+
+// How many ledger sequences of validations are kept
+const keepSequences = 256
+
+// ErrUntrusted is returned for a validation by a validator which is not on
+// the UNL, which on a validations stream is usually to be expected
+var ErrUntrusted = errors.New("validator: untrusted validation")
+
+// Validated reports a ledger which reached quorum
+type Validated struct {
+	LedgerSequence uint32
+	LedgerHash     data.Hash256
+	Validators     []data.PublicKey
+	Quorum         int
+}
+
+// Aggregator collects validations from the validators on a UNL, whether
+// they come from websockets.ValidationStreamMsg.Validation or from peers,
+// and finds the ledgers they fully validate. Validations are attributed to
+// master keys via the validators' latest manifests. An Aggregator is safe
+// for concurrent use.
+type Aggregator struct {
+	// The fraction needed for quorum, DefaultQuorum unless set
+	Quorum float64
+
+	mu        sync.Mutex
+	manifests map[data.PublicKey]*data.Manifest
+	ephemeral map[data.PublicKey]data.PublicKey
+	negative  map[data.PublicKey]bool
+	ledgers   map[uint32]map[data.PublicKey]data.Hash256
+	validated map[uint32]data.Hash256
+	latest    uint32
+}
+
+// NewAggregator trusts the master keys. Their manifests should be added
+// before their validations, though a validator without one may sign with
+// its master key.
+func NewAggregator(masterKeys ...data.PublicKey) *Aggregator {
+	a := &Aggregator{
+		Quorum:    DefaultQuorum,
+		manifests: make(map[data.PublicKey]*data.Manifest),
+		ephemeral: make(map[data.PublicKey]data.PublicKey),
+		negative:  make(map[data.PublicKey]bool),
+		ledgers:   make(map[uint32]map[data.PublicKey]data.Hash256),
+		validated: make(map[uint32]data.Hash256),
+	}
+	for _, key := range masterKeys {
+		a.manifests[key] = nil
+	}
+	return a
+}
+
+// NewBlobAggregator trusts the validators of a verified list along with
+// their manifests
+func NewBlobAggregator(b *Blob) (*Aggregator, error) {
+	manifests, err := b.Manifests()
+	if err != nil {
+		return nil, err
+	}
+	a := NewAggregator()
+	for _, m := range manifests {
+		a.manifests[m.PublicKey] = nil
+		if _, err := a.AddManifest(m); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// AddManifest checks a manifest for a trusted validator and uses it if it
+// is later than the one already held. A revoked validator is not counted
+// again. Manifests for other validators are ignored and false is returned.
+func (a *Aggregator) AddManifest(m *data.Manifest) (bool, error) {
+	if err := data.CheckManifest(m); err != nil {
+		return false, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	current, ok := a.manifests[m.PublicKey]
+	if !ok || (current != nil && current.Sequence >= m.Sequence) {
+		return false, nil
+	}
+	if current != nil && current.SigningPubKey != nil {
+		delete(a.ephemeral, *current.SigningPubKey)
+	}
+	a.manifests[m.PublicKey] = m
+	if !m.Revoked() {
+		a.ephemeral[*m.SigningPubKey] = m.PublicKey
+	}
+	return true, nil
+}
+
+// SetNegativeUNL applies the NegativeUNL ledger entry of the latest
+// validated ledger, or clears it if n is nil
+func (a *Aggregator) SetNegativeUNL(n *data.NegativeUNL) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.negative = make(map[data.PublicKey]bool)
+	if n == nil {
+		return
+	}
+	for _, disabled := range n.DisabledValidators {
+		if disabled.PublicKey != nil {
+			a.negative[*disabled.PublicKey] = true
+		}
+	}
+}
+
+// QuorumSize is the number of validations a ledger needs
+func (a *Aggregator) QuorumSize() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.quorumSize()
+}
+
+func (a *Aggregator) quorumSize() int {
+	unl, disabled := len(a.manifests), 0
+	for key := range a.negative {
+		if _, ok := a.manifests[key]; ok {
+			disabled++
+		}
+	}
+	return int(math.Ceil(math.Max(float64(unl-disabled)*a.Quorum, float64(unl)*minimumQuorum)))
+}
+
+// Add checks and records a validation. When it brings its ledger to quorum
+// for the first time the ledger is returned. Partial validations and those
+// by validators on the NegativeUNL are checked but not counted.
+func (a *Aggregator) Add(v *data.Validation) (*Validated, error) {
+	a.mu.Lock()
+	master, ok := a.master(v.SigningPubKey)
+	a.mu.Unlock()
+	if !ok {
+		return nil, ErrUntrusted
+	}
+	valid, err := data.CheckSignature(v)
+	if err != nil {
+		return nil, fmt.Errorf("validator: validation for %d: %s", v.LedgerSequence, err)
+	}
+	if !valid {
+		return nil, fmt.Errorf("validator: validation for %d: invalid signature", v.LedgerSequence)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// The manifest may have been replaced while the signature was checked
+	if current, ok := a.master(v.SigningPubKey); !ok || current != master {
+		return nil, ErrUntrusted
+	}
+	if !v.Full() || a.negative[master] || v.LedgerSequence+keepSequences <= a.latest {
+		return nil, nil
+	}
+	ledger, ok := a.ledgers[v.LedgerSequence]
+	if !ok {
+		ledger = make(map[data.PublicKey]data.Hash256)
+		a.ledgers[v.LedgerSequence] = ledger
+	}
+	ledger[master] = v.LedgerHash
+	if v.LedgerSequence > a.latest {
+		a.latest = v.LedgerSequence
+		a.prune()
+	}
+	if _, ok := a.validated[v.LedgerSequence]; ok {
+		return nil, nil
+	}
+	var validators []data.PublicKey
+	for key, hash := range ledger {
+		if hash == v.LedgerHash {
+			validators = append(validators, key)
+		}
+	}
+	quorum := a.quorumSize()
+	if len(validators) < quorum {
+		return nil, nil
+	}
+	a.validated[v.LedgerSequence] = v.LedgerHash
+	sort.Slice(validators, func(i, j int) bool { return validators[i].String() < validators[j].String() })
+	return &Validated{
+		LedgerSequence: v.LedgerSequence,
+		LedgerHash:     v.LedgerHash,
+		Validators:     validators,
+		Quorum:         quorum,
+	}, nil
+}
+
+// Validated returns the hash of the ledger with the sequence if it has
+// reached quorum
+func (a *Aggregator) Validated(sequence uint32) (*data.Hash256, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hash, ok := a.validated[sequence]
+	return &hash, ok
+}
+
+// Votes returns the ledger hash each trusted validator has validated for the
+// sequence, keyed by master key
+func (a *Aggregator) Votes(sequence uint32) map[data.PublicKey]data.Hash256 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	votes := make(map[data.PublicKey]data.Hash256, len(a.ledgers[sequence]))
+	for key, hash := range a.ledgers[sequence] {
+		votes[key] = hash
+	}
+	return votes
+}
+
+// Returns the trusted master key for a signing key
+func (a *Aggregator) master(signing data.PublicKey) (data.PublicKey, bool) {
+	if master, ok := a.ephemeral[signing]; ok {
+		return master, true
+	}
+	m, ok := a.manifests[signing]
+	return signing, ok && m == nil
+}
+
+func (a *Aggregator) prune() {
+	for sequence := range a.ledgers {
+		if sequence+keepSequences <= a.latest {
+			delete(a.ledgers, sequence)
+			delete(a.validated, sequence)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+type AggregatorSuite struct {
+	keys    []*Keys
+	tokens  []*Token
+	blob    *Blob
+	signers []crypto.Signer
+}
+
+var _ = Suite(&AggregatorSuite{})
+
+func (s *AggregatorSuite) SetUpSuite(c *C) {
+	var manifests []*data.Manifest
+	for i := 0; i < 5; i++ {
+		keys, err := NewKeys()
+		c.Assert(err, IsNil)
+		token, err := keys.CreateToken()
+		c.Assert(err, IsNil)
+		m, err := token.ReadManifest()
+		c.Assert(err, IsNil)
+		key, err := token.Key()
+		c.Assert(err, IsNil)
+		s.keys = append(s.keys, keys)
+		s.tokens = append(s.tokens, token)
+		s.signers = append(s.signers, crypto.NewKeySigner(key, nil))
+		manifests = append(manifests, m)
+	}
+	var err error
+	s.blob, err = NewBlob(1, time.Time{}, data.Now().Time().AddDate(1, 0, 0), manifests...)
+	c.Assert(err, IsNil)
+}
+
+func (s *AggregatorSuite) validation(c *C, signer crypto.Signer, sequence uint32, hash byte) *data.Validation {
+	v := data.NewValidation(data.Hash256{hash}, sequence, time.Now())
+	c.Assert(data.SignWith(v, signer), IsNil)
+	return v
+}
+
+func (s *AggregatorSuite) aggregator(c *C) *Aggregator {
+	a, err := NewBlobAggregator(s.blob)
+	c.Assert(err, IsNil)
+	return a
+}
+
+func (s *AggregatorSuite) TestQuorum(c *C) {
+	a := s.aggregator(c)
+	c.Assert(a.QuorumSize(), Equals, 4)
+	for i := 0; i < 3; i++ {
+		validated, err := a.Add(s.validation(c, s.signers[i], 10, 1))
+		c.Assert(err, IsNil)
+		c.Assert(validated, IsNil)
+	}
+	// A vote for another ledger does not help
+	validated, err := a.Add(s.validation(c, s.signers[3], 10, 2))
+	c.Assert(err, IsNil)
+	c.Assert(validated, IsNil)
+	_, ok := a.Validated(10)
+	c.Assert(ok, Equals, false)
+	c.Assert(a.Votes(10), HasLen, 4)
+
+	// Changing its vote does
+	validated, err = a.Add(s.validation(c, s.signers[3], 10, 1))
+	c.Assert(err, IsNil)
+	c.Assert(validated, NotNil)
+	c.Assert(validated.LedgerSequence, Equals, uint32(10))
+	c.Assert(validated.LedgerHash[0], Equals, byte(1))
+	c.Assert(validated.Validators, HasLen, 4)
+	hash, ok := a.Validated(10)
+	c.Assert(ok, Equals, true)
+	c.Assert(*hash, Equals, validated.LedgerHash)
+
+	// Only reported once
+	validated, err = a.Add(s.validation(c, s.signers[4], 10, 1))
+	c.Assert(err, IsNil)
+	c.Assert(validated, IsNil)
+}
+
+func (s *AggregatorSuite) TestNegativeUNL(c *C) {
+	a := s.aggregator(c)
+	a.SetNegativeUNL(&data.NegativeUNL{
+		DisabledValidators: []data.DisabledValidator{
+			{PublicKey: &s.blobManifest(c, 0).PublicKey},
+			{PublicKey: &s.blobManifest(c, 1).PublicKey},
+		},
+	})
+	// 80% of 3 is 3, but not less than 60% of 5
+	c.Assert(a.QuorumSize(), Equals, 3)
+	for i := 0; i < 4; i++ {
+		validated, err := a.Add(s.validation(c, s.signers[i], 20, 1))
+		c.Assert(err, IsNil)
+		c.Assert(validated, IsNil, Commentf("validator %d", i))
+	}
+	validated, err := a.Add(s.validation(c, s.signers[4], 20, 1))
+	c.Assert(err, IsNil)
+	c.Assert(validated, NotNil)
+	c.Assert(validated.Quorum, Equals, 3)
+	c.Assert(validated.Validators, HasLen, 3)
+}
+
+func (s *AggregatorSuite) TestUntrusted(c *C) {
+	a := s.aggregator(c)
+	other, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	_, err = a.Add(s.validation(c, crypto.NewKeySigner(other, nil), 30, 1))
+	c.Assert(err, Equals, ErrUntrusted)
+
+	v := s.validation(c, s.signers[0], 30, 1)
+	v.LedgerHash[0] = 2
+	_, err = a.Add(v)
+	c.Assert(err, ErrorMatches, ".*invalid signature")
+
+	// Partial validations are checked but not counted
+	v = data.NewValidation(data.Hash256{1}, 30, time.Now())
+	v.SetPartial()
+	c.Assert(data.SignWith(v, s.signers[1]), IsNil)
+	validated, err := a.Add(v)
+	c.Assert(err, IsNil)
+	c.Assert(validated, IsNil)
+	c.Assert(a.Votes(30), HasLen, 0)
+}
+
+func (s *AggregatorSuite) TestManifests(c *C) {
+	a := s.aggregator(c)
+	old := s.signers[0]
+	keys := *s.keys[0]
+	token, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	m, err := token.ReadManifest()
+	c.Assert(err, IsNil)
+	ok, err := a.AddManifest(m)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	ok, err = a.AddManifest(s.blobManifest(c, 0))
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+
+	// The replaced ephemeral key is no longer trusted
+	_, err = a.Add(s.validation(c, old, 40, 1))
+	c.Assert(err, Equals, ErrUntrusted)
+	key, err := token.Key()
+	c.Assert(err, IsNil)
+	_, err = a.Add(s.validation(c, crypto.NewKeySigner(key, nil), 40, 1))
+	c.Assert(err, IsNil)
+
+	// Nor is any key after revocation
+	revocation, err := keys.Revoke()
+	c.Assert(err, IsNil)
+	ok, err = a.AddManifest(revocation)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	_, err = a.Add(s.validation(c, crypto.NewKeySigner(key, nil), 41, 1))
+	c.Assert(err, Equals, ErrUntrusted)
+}
+
+func (s *AggregatorSuite) blobManifest(c *C, i int) *data.Manifest {
+	m, err := s.tokens[i].ReadManifest()
+	c.Assert(err, IsNil)
+	return m
+}
@@ -0,0 +1,274 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// UNL is a signed validator list as served by a publisher at one of
+// rippled's [validator_list_sites]. Version 1 carries a single blob and
+// version 2 carries several in BlobsV2, so that a publisher can announce the
+// next list before the current one expires.
+type UNL struct {
+	PublicKey string       `json:"public_key"`
+	Manifest  string       `json:"manifest"`
+	Blob      string       `json:"blob,omitempty"`
+	Signature string       `json:"signature,omitempty"`
+	Version   int          `json:"version"`
+	BlobsV2   []SignedBlob `json:"blobs_v2,omitempty"`
+}
+
+// SignedBlob is a base64 blob and its signature by the publisher's
+// ephemeral key. A version 2 blob may carry a newer publisher manifest.
+type SignedBlob struct {
+	Blob      string `json:"blob"`
+	Signature string `json:"signature"`
+	Manifest  string `json:"manifest,omitempty"`
+}
+
+// Blob is the signed content of a list. Times are seconds since the Ripple
+// epoch and a blob without Effective is in effect as soon as it is read.
+type Blob struct {
+	Sequence   uint32          `json:"sequence"`
+	Effective  uint32          `json:"effective,omitempty"`
+	Expiration uint32          `json:"expiration"`
+	Validators []ListValidator `json:"validators"`
+}
+
+// ListValidator is a validator's master key in hex and its latest manifest
+type ListValidator struct {
+	PublicKey string `json:"validation_public_key"`
+	Manifest  string `json:"manifest"`
+}
+
+// NewBlob lists the validators of the manifests, which must not be revoked
+func NewBlob(sequence uint32, effective, expiration time.Time, manifests ...*data.Manifest) (*Blob, error) {
+	if !effective.IsZero() && !effective.Before(expiration) {
+		return nil, fmt.Errorf("validator: list expires before it takes effect")
+	}
+	b := &Blob{
+		Sequence:   sequence,
+		Expiration: data.NewRippleTimeFromTime(expiration).Uint32(),
+	}
+	if !effective.IsZero() {
+		b.Effective = data.NewRippleTimeFromTime(effective).Uint32()
+	}
+	for _, m := range manifests {
+		if m.Revoked() {
+			return nil, fmt.Errorf("validator: %X has been revoked", m.PublicKey.Bytes())
+		}
+		manifest, err := EncodeManifest(m)
+		if err != nil {
+			return nil, err
+		}
+		b.Validators = append(b.Validators, ListValidator{
+			PublicKey: fmt.Sprintf("%X", m.PublicKey.Bytes()),
+			Manifest:  manifest,
+		})
+	}
+	sort.Slice(b.Validators, func(i, j int) bool { return b.Validators[i].PublicKey < b.Validators[j].PublicKey })
+	return b, nil
+}
+
+func (b *Blob) EffectiveTime() time.Time {
+	return data.NewRippleTime(b.Effective).Time()
+}
+
+func (b *Blob) ExpirationTime() time.Time {
+	return data.NewRippleTime(b.Expiration).Time()
+}
+
+// InEffect is true if the blob applies at t
+func (b *Blob) InEffect(t time.Time) bool {
+	return !t.Before(b.EffectiveTime()) && t.Before(b.ExpirationTime())
+}
+
+// Manifests decodes and checks each validator's manifest
+func (b *Blob) Manifests() ([]*data.Manifest, error) {
+	manifests := make([]*data.Manifest, len(b.Validators))
+	for i, v := range b.Validators {
+		m, err := DecodeManifest(v.Manifest)
+		if err != nil {
+			return nil, fmt.Errorf("validator: %s: %s", v.PublicKey, err)
+		}
+		if !strings.EqualFold(fmt.Sprintf("%X", m.PublicKey.Bytes()), v.PublicKey) {
+			return nil, fmt.Errorf("validator: %s: manifest is for %X", v.PublicKey, m.PublicKey.Bytes())
+		}
+		manifests[i] = m
+	}
+	return manifests, nil
+}
+
+// Publisher signs validator lists with the ephemeral key of a token
+type Publisher struct {
+	token    *Token
+	manifest *data.Manifest
+	signer   crypto.Signer
+}
+
+func NewPublisher(token *Token) (*Publisher, error) {
+	manifest, err := token.ReadManifest()
+	if err != nil {
+		return nil, err
+	}
+	key, err := token.Key()
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		token:    token,
+		manifest: manifest,
+		signer:   crypto.NewKeySigner(key, nil),
+	}, nil
+}
+
+// PublicKey is the publisher's master key in hex, as configured in rippled's
+// [validator_list_keys]
+func (p *Publisher) PublicKey() string {
+	return fmt.Sprintf("%X", p.manifest.PublicKey.Bytes())
+}
+
+// Sign encodes and signs a blob
+func (p *Publisher) Sign(b *Blob) (*SignedBlob, error) {
+	blob, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := p.signer.Sign(crypto.Sha512Half(blob), blob)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedBlob{
+		Blob:      base64.StdEncoding.EncodeToString(blob),
+		Signature: fmt.Sprintf("%X", signature),
+	}, nil
+}
+
+// UNL signs the blobs as a list of the version, which must be 1 or 2.
+// Version 1 lists have exactly one blob.
+func (p *Publisher) UNL(version int, blobs ...*Blob) (*UNL, error) {
+	l := &UNL{
+		PublicKey: p.PublicKey(),
+		Manifest:  p.token.Manifest,
+		Version:   version,
+	}
+	switch {
+	case version == 1 && len(blobs) == 1:
+		signed, err := p.Sign(blobs[0])
+		if err != nil {
+			return nil, err
+		}
+		l.Blob, l.Signature = signed.Blob, signed.Signature
+	case version == 2 && len(blobs) > 0:
+		for _, b := range blobs {
+			signed, err := p.Sign(b)
+			if err != nil {
+				return nil, err
+			}
+			l.BlobsV2 = append(l.BlobsV2, *signed)
+		}
+	default:
+		return nil, fmt.Errorf("validator: cannot make a version %d list of %d blobs", version, len(blobs))
+	}
+	return l, nil
+}
+
+// Verify checks that the list is published by the master key, given in hex,
+// and returns its blobs. Every signature and validator manifest is checked
+// but the blobs' times are not.
+func (l *UNL) Verify(publisherKey string) ([]*Blob, error) {
+	if !strings.EqualFold(l.PublicKey, publisherKey) {
+		return nil, fmt.Errorf("validator: list is published by %s", l.PublicKey)
+	}
+	var signed []SignedBlob
+	switch l.Version {
+	case 1:
+		signed = []SignedBlob{{Blob: l.Blob, Signature: l.Signature}}
+	case 2:
+		signed = l.BlobsV2
+	default:
+		return nil, fmt.Errorf("validator: unsupported list version: %d", l.Version)
+	}
+	if len(signed) == 0 {
+		return nil, fmt.Errorf("validator: list has no blobs")
+	}
+	publisher, err := l.publisherManifest(l.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([]*Blob, len(signed))
+	for i, s := range signed {
+		m := publisher
+		if s.Manifest != "" {
+			if m, err = l.publisherManifest(s.Manifest); err != nil {
+				return nil, err
+			}
+		}
+		if blobs[i], err = s.verify(m); err != nil {
+			return nil, err
+		}
+	}
+	return blobs, nil
+}
+
+func (l *UNL) publisherManifest(s string) (*data.Manifest, error) {
+	m, err := DecodeManifest(s)
+	if err != nil {
+		return nil, fmt.Errorf("validator: publisher manifest: %s", err)
+	}
+	if !strings.EqualFold(fmt.Sprintf("%X", m.PublicKey.Bytes()), l.PublicKey) {
+		return nil, fmt.Errorf("validator: publisher manifest is for %X", m.PublicKey.Bytes())
+	}
+	if m.Revoked() {
+		return nil, fmt.Errorf("validator: publisher %s has been revoked", l.PublicKey)
+	}
+	return m, nil
+}
+
+func (s *SignedBlob) verify(publisher *data.Manifest) (*Blob, error) {
+	blob, err := base64.StdEncoding.DecodeString(s.Blob)
+	if err != nil {
+		return nil, fmt.Errorf("validator: bad blob: %s", err)
+	}
+	signature, err := hex.DecodeString(s.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("validator: bad blob signature: %s", err)
+	}
+	ok, err := crypto.Verify(publisher.SigningPubKey.Bytes(), crypto.Sha512Half(blob), blob, signature)
+	if err != nil {
+		return nil, fmt.Errorf("validator: blob signature: %s", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("validator: blob signature: invalid")
+	}
+	var b Blob
+	if err := json.Unmarshal(blob, &b); err != nil {
+		return nil, fmt.Errorf("validator: bad blob: %s", err)
+	}
+	if _, err := b.Manifests(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Current returns the blob with the highest sequence in effect at t
+func Current(blobs []*Blob, t time.Time) (*Blob, error) {
+	var current *Blob
+	for _, b := range blobs {
+		if b.InEffect(t) && (current == nil || b.Sequence > current.Sequence) {
+			current = b
+		}
+	}
+	if current == nil {
+		return nil, fmt.Errorf("validator: no list is in effect at %s", t.UTC().Format(time.RFC3339))
+	}
+	return current, nil
+}
@@ -0,0 +1,118 @@
+package validator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+type ListSuite struct {
+	publisher  *Publisher
+	manifests  []*data.Manifest
+	start, end time.Time
+}
+
+var _ = Suite(&ListSuite{})
+
+func (s *ListSuite) SetUpSuite(c *C) {
+	keys, err := NewKeys()
+	c.Assert(err, IsNil)
+	token, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	s.publisher, err = NewPublisher(token)
+	c.Assert(err, IsNil)
+	for i := 0; i < 3; i++ {
+		keys, err := NewKeys()
+		c.Assert(err, IsNil)
+		token, err := keys.CreateToken()
+		c.Assert(err, IsNil)
+		m, err := token.ReadManifest()
+		c.Assert(err, IsNil)
+		s.manifests = append(s.manifests, m)
+	}
+	s.start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.end = s.start.AddDate(0, 6, 0)
+}
+
+// Round trips the list through JSON
+func (s *ListSuite) verify(c *C, l *UNL) ([]*Blob, error) {
+	b, err := json.Marshal(l)
+	c.Assert(err, IsNil)
+	var decoded UNL
+	c.Assert(json.Unmarshal(b, &decoded), IsNil)
+	return decoded.Verify(s.publisher.PublicKey())
+}
+
+func (s *ListSuite) TestVersion1(c *C) {
+	blob, err := NewBlob(1, time.Time{}, s.end, s.manifests...)
+	c.Assert(err, IsNil)
+	l, err := s.publisher.UNL(1, blob)
+	c.Assert(err, IsNil)
+	c.Assert(l.BlobsV2, IsNil)
+	blobs, err := s.verify(c, l)
+	c.Assert(err, IsNil)
+	c.Assert(blobs, DeepEquals, []*Blob{blob})
+	manifests, err := blobs[0].Manifests()
+	c.Assert(err, IsNil)
+	c.Assert(manifests, HasLen, 3)
+	c.Assert(blobs[0].ExpirationTime().Equal(s.end), Equals, true)
+
+	_, err = l.Verify("ED00")
+	c.Assert(err, ErrorMatches, "validator: list is published by .*")
+	_, err = s.publisher.UNL(1, blob, blob)
+	c.Assert(err, ErrorMatches, ".*version 1 list of 2 blobs")
+}
+
+func (s *ListSuite) TestVersion2(c *C) {
+	current, err := NewBlob(1, time.Time{}, s.end, s.manifests[:2]...)
+	c.Assert(err, IsNil)
+	next, err := NewBlob(2, s.end.AddDate(0, -1, 0), s.end.AddDate(1, 0, 0), s.manifests...)
+	c.Assert(err, IsNil)
+	l, err := s.publisher.UNL(2, current, next)
+	c.Assert(err, IsNil)
+	c.Assert(l.Blob, Equals, "")
+	blobs, err := s.verify(c, l)
+	c.Assert(err, IsNil)
+	c.Assert(blobs, HasLen, 2)
+
+	for _, t := range []struct {
+		at       time.Time
+		sequence uint32
+	}{
+		{s.start, 1},
+		{s.end.AddDate(0, 0, -1), 2},
+		{s.end.AddDate(0, 1, 0), 2},
+	} {
+		b, err := Current(blobs, t.at)
+		c.Assert(err, IsNil)
+		c.Assert(b.Sequence, Equals, t.sequence, Commentf("%s", t.at))
+	}
+	_, err = Current(blobs, s.end.AddDate(2, 0, 0))
+	c.Assert(err, ErrorMatches, "validator: no list is in effect at .*")
+}
+
+func (s *ListSuite) TestTampered(c *C) {
+	blob, err := NewBlob(1, time.Time{}, s.end, s.manifests...)
+	c.Assert(err, IsNil)
+	l, err := s.publisher.UNL(1, blob)
+	c.Assert(err, IsNil)
+
+	// Drop a validator without re-signing
+	blob.Validators = blob.Validators[1:]
+	b, err := json.Marshal(blob)
+	c.Assert(err, IsNil)
+	l.Blob = base64.StdEncoding.EncodeToString(b)
+	_, err = s.verify(c, l)
+	c.Assert(err, ErrorMatches, "validator: blob signature: invalid")
+
+	// Swap a validator's manifest for another's
+	blob.Validators[0].Manifest = blob.Validators[1].Manifest
+	signed, err := s.publisher.Sign(blob)
+	c.Assert(err, IsNil)
+	l.Blob, l.Signature = signed.Blob, signed.Signature
+	_, err = s.verify(c, l)
+	c.Assert(err, ErrorMatches, "validator: .*: manifest is for .*")
+}
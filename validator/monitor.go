@@ -0,0 +1,198 @@
+package validator
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Stats describes how well a validator has kept up with the network
+type Stats struct {
+	// The master key, or the signing key if there is no manifest
+	Validator  string `json:"validator"`
+	SigningKey string `json:"signing_key"`
+
+	Validations uint64 `json:"validations"`
+	Agreed      uint64 `json:"agreed"`
+	Disagreed   uint64 `json:"disagreed"`
+	Missed      uint64 `json:"missed"`
+	// True if the validator agreed with the last validated ledger
+	InSync bool `json:"in_sync"`
+	// How long after the ledger closed this validator's validation arrived,
+	// on average. Close times are rounded to the ledger's close time
+	// resolution, so this is only a rough measure.
+	Latency        time.Duration `json:"-"`
+	LatencyMillis  int64         `json:"latency_ms"`
+	LastSequence   uint32        `json:"last_sequence"`
+	LastValidation time.Time     `json:"last_validation"`
+
+	// Votes from the last flag ledger validation
+	Amendments       data.Vector256 `json:"amendments,omitempty"`
+	BaseFee          *uint64        `json:"base_fee,omitempty"`
+	ReserveBase      *uint32        `json:"reserve_base,omitempty"`
+	ReserveIncrement *uint32        `json:"reserve_inc,omitempty"`
+
+	totalLatency time.Duration
+	latencies    int64
+	first        uint32
+}
+
+// Agreement is the fraction of validated ledgers the validator agreed with
+func (s *Stats) Agreement() float64 {
+	total := s.Agreed + s.Disagreed + s.Missed
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Agreed) / float64(total)
+}
+
+type vote struct {
+	hash     data.Hash256
+	received time.Time
+}
+
+type round struct {
+	votes map[string]vote
+}
+
+type closedLedger struct {
+	hash      data.Hash256
+	closeTime time.Time
+}
+
+// Monitor keeps Stats for every validator it sees. Validations are compared
+// with the validated ledger once the next ledger is validated, which allows
+// for validations which arrive after the ledger stream message. A Monitor
+// is safe for concurrent use.
+type Monitor struct {
+	mu        sync.Mutex
+	stats     map[string]*Stats
+	rounds    map[uint32]*round
+	validated map[uint32]closedLedger
+	latest    uint32
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{
+		stats:     make(map[string]*Stats),
+		rounds:    make(map[uint32]*round),
+		validated: make(map[uint32]closedLedger),
+	}
+}
+
+// AddValidation records a validation, which should already have been
+// checked, from the validator with the master key, or an empty string if it
+// is not known
+func (m *Monitor) AddValidation(masterKey string, v *data.Validation, received time.Time) {
+	signingKey := v.SigningPubKey.NodePublicKey()
+	if masterKey == "" {
+		masterKey = signingKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stats[masterKey]
+	if !ok {
+		s = &Stats{Validator: masterKey, first: v.LedgerSequence}
+		m.stats[masterKey] = s
+	}
+	s.SigningKey = signingKey
+	s.Validations++
+	if v.LedgerSequence > s.LastSequence {
+		s.LastSequence = v.LedgerSequence
+		s.LastValidation = received
+	}
+	if len(v.Amendments) > 0 {
+		s.Amendments = v.Amendments
+	}
+	if v.BaseFee != nil {
+		s.BaseFee, s.ReserveBase, s.ReserveIncrement = v.BaseFee, v.ReserveBase, v.ReserveIncrement
+	}
+	if v.LedgerSequence < m.latest {
+		// Too late to count
+		return
+	}
+	r, ok := m.rounds[v.LedgerSequence]
+	if !ok {
+		r = &round{votes: make(map[string]vote)}
+		m.rounds[v.LedgerSequence] = r
+	}
+	r.votes[masterKey] = vote{v.LedgerHash, received}
+}
+
+// LedgerValidated settles the validations for ledgers validated before
+// this one. Latency is measured from the close time, if it is not zero.
+func (m *Monitor) LedgerValidated(sequence uint32, hash data.Hash256, closeTime time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validated[sequence] = closedLedger{hash, closeTime}
+	if sequence > m.latest {
+		m.latest = sequence
+	}
+	for seq, r := range m.rounds {
+		if seq >= sequence {
+			continue
+		}
+		if validated, ok := m.validated[seq]; ok {
+			m.settle(seq, validated, r)
+		}
+		delete(m.rounds, seq)
+	}
+	for seq := range m.validated {
+		if seq+keepSequences <= sequence {
+			delete(m.validated, seq)
+		}
+	}
+}
+
+func (m *Monitor) settle(sequence uint32, ledger closedLedger, r *round) {
+	for key, s := range m.stats {
+		v, ok := r.votes[key]
+		switch {
+		case ok && v.hash == ledger.hash:
+			s.Agreed++
+		case ok:
+			s.Disagreed++
+		case s.first < sequence:
+			s.Missed++
+		default:
+			continue
+		}
+		s.InSync = ok && v.hash == ledger.hash
+		if ok && !ledger.closeTime.IsZero() {
+			// A rounded close time can be after the validation
+			if latency := v.received.Sub(ledger.closeTime); latency > 0 {
+				s.totalLatency += latency
+			}
+			s.latencies++
+			s.Latency = s.totalLatency / time.Duration(s.latencies)
+			s.LatencyMillis = s.Latency.Milliseconds()
+		}
+	}
+}
+
+// Stats returns a copy of every validator's stats, sorted by validator
+func (m *Monitor) Stats() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]Stats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Validator < stats[j].Validator })
+	return stats
+}
+
+// AmendmentVotes counts the validators voting for each amendment
+func (m *Monitor) AmendmentVotes() map[data.Hash256]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	votes := make(map[data.Hash256]int)
+	for _, s := range m.stats {
+		for _, amendment := range s.Amendments {
+			votes[amendment]++
+		}
+	}
+	return votes
+}
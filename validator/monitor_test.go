@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"time"
+
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+type MonitorSuite struct{}
+
+var _ = Suite(&MonitorSuite{})
+
+func monitorValidation(key byte, sequence uint32, hash byte) *data.Validation {
+	v := &data.Validation{LedgerSequence: sequence}
+	v.SigningPubKey[0], v.SigningPubKey[1] = 0x02, key
+	v.LedgerHash[0] = hash
+	return v
+}
+
+func (s *MonitorSuite) TestStats(c *C) {
+	m := NewMonitor()
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	m.AddValidation("A", monitorValidation(1, 100, 1), at(0))
+	m.AddValidation("B", monitorValidation(2, 100, 1), at(200))
+	m.AddValidation("C", monitorValidation(3, 100, 2), at(400))
+	m.LedgerValidated(100, data.Hash256{1}, at(-500))
+	// B misses 101 and C's validation arrives after the ledger stream message
+	m.AddValidation("A", monitorValidation(1, 101, 3), at(4000))
+	m.LedgerValidated(101, data.Hash256{3}, at(3500))
+	m.AddValidation("C", monitorValidation(3, 101, 3), at(4100))
+	// D joins late and is not counted as missing earlier ledgers
+	m.AddValidation("D", monitorValidation(4, 102, 4), at(8000))
+	m.LedgerValidated(102, data.Hash256{4}, at(7000))
+	m.LedgerValidated(103, data.Hash256{5}, at(12000))
+	// Too late
+	m.AddValidation("B", monitorValidation(2, 101, 3), at(9000))
+
+	stats := m.Stats()
+	c.Assert(stats, HasLen, 4)
+	for i, expected := range []struct {
+		agreed, disagreed, missed uint64
+		inSync                    bool
+		latency                   int64
+	}{
+		{2, 0, 1, false, 500},
+		{1, 0, 2, false, 700},
+		{1, 1, 1, false, 750},
+		{1, 0, 0, true, 1000},
+	} {
+		comment := Commentf("%s", stats[i].Validator)
+		c.Assert(stats[i].Agreed, Equals, expected.agreed, comment)
+		c.Assert(stats[i].Disagreed, Equals, expected.disagreed, comment)
+		c.Assert(stats[i].Missed, Equals, expected.missed, comment)
+		c.Assert(stats[i].InSync, Equals, expected.inSync, comment)
+		c.Assert(stats[i].LatencyMillis, Equals, expected.latency, comment)
+	}
+	c.Assert(stats[1].Validations, Equals, uint64(2))
+	c.Assert(stats[3].Agreement(), Equals, 1.0)
+}
+
+func (s *MonitorSuite) TestVotes(c *C) {
+	m := NewMonitor()
+	fee := uint64(10)
+	for i, amendments := range []data.Vector256{{{1}, {2}}, {{2}}, nil} {
+		v := monitorValidation(byte(i), 256, 1)
+		v.Amendments = amendments
+		v.BaseFee = &fee
+		m.AddValidation("", v, time.Now())
+	}
+	c.Assert(m.AmendmentVotes(), DeepEquals, map[data.Hash256]int{{1}: 1, {2}: 2})
+	for _, stats := range m.Stats() {
+		c.Assert(*stats.BaseFee, Equals, fee)
+	}
+}
@@ -0,0 +1,247 @@
+// Package validator manages a validator's keys in the same way as rippled's
+// validator-keys tool. The long lived master key, usually kept offline,
+// signs manifests which delegate to an ephemeral key. Each manifest and its
+// ephemeral key are given to rippled as a validator token and a new token
+// replaces the last, so the master key is never needed on the validator.
+package validator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Keys is the master key file, which is compatible with validator-keys
+type Keys struct {
+	KeyType       string `json:"key_type"`
+	PublicKey     string `json:"public_key"`
+	SecretKey     string `json:"secret_key"`
+	TokenSequence uint32 `json:"token_sequence"`
+	Revoked       bool   `json:"revoked"`
+	Domain        string `json:"domain,omitempty"`
+}
+
+// NewKeys generates a random ed25519 master key
+func NewKeys() (*Keys, error) {
+	key, err := crypto.NewEd25519Key(nil)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := crypto.NewNodePrivateKey(key.Private(nil)[:32])
+	if err != nil {
+		return nil, err
+	}
+	public, err := crypto.NodePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{
+		KeyType:   "ed25519",
+		PublicKey: public.String(),
+		SecretKey: secret.String(),
+	}, nil
+}
+
+// ReadKeys reads a key file and checks that its keys match
+func ReadKeys(r io.Reader) (*Keys, error) {
+	var k Keys
+	if err := json.NewDecoder(r).Decode(&k); err != nil {
+		return nil, err
+	}
+	key, err := k.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+	public, err := crypto.NodePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if public.String() != k.PublicKey {
+		return nil, fmt.Errorf("validator: secret_key does not match public_key %s", k.PublicKey)
+	}
+	return &k, nil
+}
+
+func (k *Keys) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "   ")
+	return enc.Encode(k)
+}
+
+// MasterKey decodes the secret key
+func (k *Keys) MasterKey() (crypto.Key, error) {
+	secret, err := crypto.NewRippleHashCheck(k.SecretKey, crypto.RIPPLE_NODE_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	switch k.KeyType {
+	case "ed25519":
+		return crypto.NewEd25519KeyFromSecret(secret.Payload())
+	case "secp256k1":
+		return crypto.NewECDSAKeyFromPrivate(secret.Payload())
+	default:
+		return nil, fmt.Errorf("validator: unknown key type: %s", k.KeyType)
+	}
+}
+
+// CreateToken generates a new secp256k1 ephemeral key and a manifest for it
+// with the next token sequence, which replaces any earlier token. The keys
+// must be saved afterwards so that the sequence is not reused.
+func (k *Keys) CreateToken() (*Token, error) {
+	if k.Revoked {
+		return nil, fmt.Errorf("validator: %s has been revoked", k.PublicKey)
+	}
+	if k.TokenSequence+1 >= data.ManifestRevoked {
+		return nil, fmt.Errorf("validator: %s has no token sequences left", k.PublicKey)
+	}
+	ephemeral, err := crypto.NewECDSAKey(nil)
+	if err != nil {
+		return nil, err
+	}
+	m, err := k.sign(k.TokenSequence+1, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	k.TokenSequence++
+	return NewToken(m, ephemeral)
+}
+
+// Revoke creates a manifest which permanently revokes the master key. The
+// keys must be saved afterwards.
+func (k *Keys) Revoke() (*data.Manifest, error) {
+	m, err := k.sign(data.ManifestRevoked, nil)
+	if err != nil {
+		return nil, err
+	}
+	k.Revoked = true
+	k.TokenSequence = data.ManifestRevoked
+	return m, nil
+}
+
+func (k *Keys) sign(sequence uint32, ephemeral crypto.Key) (*data.Manifest, error) {
+	master, err := k.MasterKey()
+	if err != nil {
+		return nil, err
+	}
+	m := &data.Manifest{Sequence: sequence}
+	if k.Domain != "" {
+		domain := data.VariableLength(strings.ToLower(k.Domain))
+		m.Domain = &domain
+	}
+	var signer crypto.Signer
+	if ephemeral != nil {
+		signer = crypto.NewKeySigner(ephemeral, nil)
+	}
+	if err := data.SignManifest(m, crypto.NewKeySigner(master, nil), signer); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Token is what rippled's [validator_token] stanza holds
+type Token struct {
+	Manifest            string `json:"manifest"`
+	ValidationSecretKey string `json:"validation_secret_key"`
+}
+
+func NewToken(m *data.Manifest, ephemeral crypto.Key) (*Token, error) {
+	manifest, err := EncodeManifest(m)
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	private := ephemeral.Private(nil)
+	copy(secret[32-len(private):], private)
+	return &Token{
+		Manifest:            manifest,
+		ValidationSecretKey: fmt.Sprintf("%X", secret),
+	}, nil
+}
+
+// ParseToken accepts the base64 token with or without the [validator_token]
+// header and line breaks
+func ParseToken(s string) (*Token, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "[validator_token]"))
+	b, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, fmt.Errorf("validator: bad token: %s", err)
+	}
+	var t Token
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("validator: bad token: %s", err)
+	}
+	return &t, nil
+}
+
+// String is the token as a single line of base64
+func (t *Token) String() string {
+	b, _ := json.Marshal(t)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// Config is the token as a stanza for rippled.cfg
+func (t *Token) Config() string {
+	s := t.String()
+	lines := []string{"[validator_token]"}
+	for len(s) > 72 {
+		lines, s = append(lines, s[:72]), s[72:]
+	}
+	return strings.Join(append(lines, s), "\n")
+}
+
+// ReadManifest decodes and checks the token's manifest
+func (t *Token) ReadManifest() (*data.Manifest, error) {
+	return DecodeManifest(t.Manifest)
+}
+
+// Key decodes the ephemeral key and checks it is the one in the manifest
+func (t *Token) Key() (crypto.Key, error) {
+	m, err := t.ReadManifest()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := hex.DecodeString(t.ValidationSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.NewECDSAKeyFromPrivate(secret)
+	if err != nil {
+		return nil, err
+	}
+	if m.SigningPubKey == nil || !bytes.Equal(key.Public(nil), m.SigningPubKey.Bytes()) {
+		return nil, fmt.Errorf("validator: validation_secret_key is not the manifest's ephemeral key")
+	}
+	return key, nil
+}
+
+// EncodeManifest returns the manifest in base64 as rippled expects it
+func EncodeManifest(m *data.Manifest) (string, error) {
+	_, raw, err := data.Raw(m)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// DecodeManifest decodes a base64 manifest and checks its signatures
+func DecodeManifest(s string) (*data.Manifest, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	m, err := data.ReadManifest(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	if err := data.CheckManifest(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
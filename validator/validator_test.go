@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rubblelabs/ripple/crypto"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type ValidatorSuite struct{}
+
+var _ = Suite(&ValidatorSuite{})
+
+func (s *ValidatorSuite) TestToken(c *C) {
+	keys, err := NewKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys.PublicKey, Matches, "nH.*")
+	keys.Domain = "Example.com"
+
+	first, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	token, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	c.Assert(keys.TokenSequence, Equals, uint32(2))
+
+	// Round trip the keys file and the config stanza
+	var buf bytes.Buffer
+	c.Assert(keys.Write(&buf), IsNil)
+	keys, err = ReadKeys(&buf)
+	c.Assert(err, IsNil)
+	config := token.Config()
+	c.Assert(strings.HasPrefix(config, "[validator_token]\n"), Equals, true)
+	for _, line := range strings.Split(config, "\n") {
+		c.Assert(len(line) <= 72, Equals, true)
+	}
+	token, err = ParseToken(config)
+	c.Assert(err, IsNil)
+
+	m, err := token.ReadManifest()
+	c.Assert(err, IsNil)
+	c.Assert(m.Sequence, Equals, uint32(2))
+	c.Assert(string(*m.Domain), Equals, "example.com")
+	master, err := crypto.NewNodePublicKey(m.PublicKey.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(master.String(), Equals, keys.PublicKey)
+	ephemeral, err := token.Key()
+	c.Assert(err, IsNil)
+	c.Assert(ephemeral.Public(nil), DeepEquals, m.SigningPubKey.Bytes())
+	c.Assert(m.Hash.IsZero(), Equals, false)
+
+	// A token with another token's secret is refused
+	first.ValidationSecretKey = token.ValidationSecretKey
+	_, err = first.Key()
+	c.Assert(err, ErrorMatches, ".*not the manifest's ephemeral key")
+}
+
+func (s *ValidatorSuite) TestRevoke(c *C) {
+	keys, err := NewKeys()
+	c.Assert(err, IsNil)
+	m, err := keys.Revoke()
+	c.Assert(err, IsNil)
+	c.Assert(m.Revoked(), Equals, true)
+	c.Assert(m.SigningPubKey, IsNil)
+	encoded, err := EncodeManifest(m)
+	c.Assert(err, IsNil)
+	decoded, err := DecodeManifest(encoded)
+	c.Assert(err, IsNil)
+	c.Assert(decoded.Revoked(), Equals, true)
+	c.Assert(decoded.Hash, Equals, m.Hash)
+	_, err = keys.CreateToken()
+	c.Assert(err, ErrorMatches, ".*has been revoked")
+}
+
+func (s *ValidatorSuite) TestTampered(c *C) {
+	keys, err := NewKeys()
+	c.Assert(err, IsNil)
+	token, err := keys.CreateToken()
+	c.Assert(err, IsNil)
+	m, err := token.ReadManifest()
+	c.Assert(err, IsNil)
+	m.Sequence = 100
+	encoded, err := EncodeManifest(m)
+	c.Assert(err, IsNil)
+	_, err = DecodeManifest(encoded)
+	c.Assert(err, ErrorMatches, "Manifest 100 master signature: invalid")
+
+	// A master key which signs for itself
+	other, err := NewKeys()
+	c.Assert(err, IsNil)
+	keys.SecretKey = other.SecretKey
+	var buf bytes.Buffer
+	c.Assert(keys.Write(&buf), IsNil)
+	_, err = ReadKeys(&buf)
+	c.Assert(err, ErrorMatches, "validator: secret_key does not match.*")
+}
+
+// The manifest of the vl.ripple.com publisher key, as signed by rippled's
+// validator-keys tool
+const rippleManifest = "JAAAAAFxIe1FtwmimvGtH2iCcMJqC9gVFKilGfw1/vCxHXXLplc2GnMhAkE1agqXxBwDwDbID6OMSYuM0FDAlpAgNk8SKFn7MO2fdkcwRQIhAOngu9sAKqXYouJ+l2V0W+sAOkVB+ZRS6PShlJAfUsXfAiBsVJGesaadOJc/aAZokS1vymGmVrlHPKWX3Yywu6in8HASQKPugBD67kMaRFGvmpATHlGKJdvDFlWPYy5AqDedFv5TJa2w0i21eq3MYywLVJZnFOr7C0kw2AiTzSCjIzditQ8="
+
+func (s *ValidatorSuite) TestRippledManifest(c *C) {
+	m, err := DecodeManifest(rippleManifest)
+	c.Assert(err, IsNil)
+	c.Assert(m.Sequence, Equals, uint32(1))
+	c.Assert(m.Revoked(), Equals, false)
+	c.Assert(m.Domain, IsNil)
+	master, err := crypto.NewNodePublicKey(m.PublicKey.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(master.String(), Equals, "nHBt9fsb4849WmZiCds4r5TXyBeQjqnH5kzPtqgMAQMgi39YZRPa")
+	ephemeral, err := crypto.NewNodePublicKey(m.SigningPubKey.Bytes())
+	c.Assert(err, IsNil)
+	c.Assert(ephemeral.String(), Equals, "n9KsDYGKhABVc4wK5u3MnVhgPinyJimyKGpr9VJYuBaY8EnJXR2x")
+
+	// The fields are written back in rippled's order
+	encoded, err := EncodeManifest(m)
+	c.Assert(err, IsNil)
+	c.Assert(encoded, Equals, rippleManifest)
+
+	// A token needs the ephemeral key which signed the manifest
+	key, err := crypto.NewECDSAKey(nil)
+	c.Assert(err, IsNil)
+	token := &Token{Manifest: rippleManifest, ValidationSecretKey: fmt.Sprintf("%X", key.Private(nil))}
+	_, err = token.Key()
+	c.Assert(err, ErrorMatches, ".*not the manifest's ephemeral key")
+}
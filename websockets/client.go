@@ -0,0 +1,70 @@
+package websockets
+
+import (
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Client is the request/response command set available over both the
+// WebSocket Remote and the HTTP JSON-RPC RPCClient. Streams and
+// subscriptions are only available from a Remote.
+type Client interface {
+	Tx(hash data.Hash256) (*TxResult, error)
+	AccountTx(account data.Account, pageSize int, minLedger, maxLedger int64) chan *data.TransactionWithMetaData
+	Submit(tx data.Transaction) (*SubmitResult, error)
+	SubmitBatch(txs []data.Transaction) ([]*SubmitResult, error)
+	LedgerData(ledger interface{}, marker *data.Hash256) (*LedgerDataResult, error)
+	StreamLedgerData(ledger interface{}) chan data.LedgerEntrySlice
+	Ledger(ledger interface{}, transactions bool) (*LedgerResult, error)
+	LedgerHeader(ledger interface{}) (*LedgerHeaderResult, error)
+	RipplePathFind(src, dest data.Account, amount data.Amount, srcCurr *[]data.Currency) (*RipplePathFindResult, error)
+	AccountInfo(a data.Account) (*AccountInfoResult, error)
+	AccountLines(account data.Account, ledgerIndex interface{}) (*AccountLinesResult, error)
+	AccountOffers(account data.Account, ledgerIndex interface{}) (*AccountOffersResult, error)
+	AccountObjects(account data.Account, typ string, ledgerIndex interface{}) (*AccountObjectsResult, error)
+	BookOffers(taker data.Account, ledgerIndex interface{}, pays, gets data.Asset) (*BookOffersResult, error)
+	Fee() (*FeeResult, error)
+
+	LedgerEntry(index data.Hash256, ledger interface{}) (*LedgerEntryResult, error)
+	AccountRootEntry(account data.Account, ledger interface{}) (*data.AccountRoot, error)
+	RippleStateEntry(a, b data.Account, currency data.Currency, ledger interface{}) (*data.RippleState, error)
+	OfferEntry(account data.Account, sequence uint32, ledger interface{}) (*data.Offer, error)
+	DirectoryEntry(root data.Hash256, page uint64, ledger interface{}) (*data.Directory, error)
+	EscrowEntry(owner data.Account, sequence uint32, ledger interface{}) (*data.Escrow, error)
+	CheckEntry(account data.Account, sequence uint32, ledger interface{}) (*data.Check, error)
+	PaymentChannelEntry(account, destination data.Account, sequence uint32, ledger interface{}) (*data.PayChannel, error)
+	TicketEntry(account data.Account, ticketSequence uint32, ledger interface{}) (*data.Ticket, error)
+	AMMEntry(asset, asset2 data.Issue, ledger interface{}) (*data.AMM, error)
+
+	ServerInfo() (*ServerInfoResult, error)
+	ServerState() (*ServerStateResult, error)
+	LedgerClosed() (*LedgerClosedResult, error)
+	LedgerCurrent() (*LedgerCurrentResult, error)
+	Manifest(publicKey string) (*ManifestResult, error)
+	Feature(feature string) (*FeatureResult, error)
+	Amendments(ledger interface{}) ([]AmendmentStatus, error)
+
+	Sign(tx data.Transaction, seed data.Seed, keyType data.KeyType, offline bool) (*SignResult, error)
+	SignFor(tx data.Transaction, account data.Account, seed data.Seed, keyType data.KeyType) (*SignResult, error)
+	SubmitMultisigned(tx data.Transaction) (*SubmitResult, error)
+	Simulate(tx data.Transaction) (*SimulateResult, error)
+}
+
+var (
+	_ Client = (*Remote)(nil)
+	_ Client = (*RPCClient)(nil)
+)
+
+// requester implements Client on top of a transport specific sender, which
+// must eventually signal the command's Ready channel via Done or Fail.
+type requester struct {
+	sender func(cmd Syncer)
+}
+
+// Fails the command if the client was not made by NewRemote or NewRPCClient
+func (r *requester) send(cmd Syncer) {
+	if r.sender == nil {
+		go cmd.Fail("Not connected, use NewRemote or NewRPCClient")
+		return
+	}
+	r.sender(cmd)
+}
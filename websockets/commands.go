@@ -261,6 +261,24 @@ type AccountOffersResult struct {
 	Offers         data.AccountOfferSlice `json:"offers"`
 }
 
+// https://xrpl.org/account_objects.html
+type AccountObjectsCommand struct {
+	*Command
+	Account     data.Account          `json:"account"`
+	Type        string                `json:"type,omitempty"`
+	Limit       uint32                `json:"limit"`
+	LedgerIndex interface{}           `json:"ledger_index,omitempty"`
+	Marker      interface{}           `json:"marker,omitempty"`
+	Result      *AccountObjectsResult `json:"result,omitempty"`
+}
+
+type AccountObjectsResult struct {
+	LedgerSequence *uint32               `json:"ledger_index"`
+	Account        data.Account          `json:"account"`
+	Marker         interface{}           `json:"marker"`
+	AccountObjects data.LedgerEntrySlice `json:"account_objects"`
+}
+
 type BookOffersCommand struct {
 	*Command
 	LedgerIndex interface{}  `json:"ledger_index,omitempty"`
@@ -300,3 +318,12 @@ type FeeResult struct {
 	MaxQueueSize uint32 `json:"max_queue_size,string"`
 	Status       string `json:"status"`
 }
+
+// Suggested returns the fee needed to get into the open ledger, which is
+// never less than the base fee
+func (r *FeeResult) Suggested() data.Value {
+	if r.Drops.OpenLedgerFee.Less(r.Drops.BaseFee) {
+		return r.Drops.BaseFee
+	}
+	return r.Drops.OpenLedgerFee
+}
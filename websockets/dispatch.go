@@ -0,0 +1,271 @@
+package websockets
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/glog"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Backpressure decides what happens to a stream message when the
+// Incoming buffer is full.
+type Backpressure int32
+
+const (
+	// Wait for the consumer, stalling command responses until it catches up
+	Block Backpressure = iota
+	// Discard the oldest buffered message to make room
+	DropOldest
+	// Close the connection, failing any pending commands
+	Disconnect
+)
+
+func (b Backpressure) String() string {
+	switch b {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return "unknown"
+	}
+}
+
+// Sets the policy applied when the Incoming buffer is full. The default is Block.
+func (r *Remote) SetBackpressure(policy Backpressure) {
+	atomic.StoreInt32((*int32)(&r.backpressure), int32(policy))
+}
+
+// Number of stream messages discarded because the Incoming buffer was full
+func (r *Remote) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
+
+// Delivers a stream message to the Incoming channel according to the
+// backpressure policy. Returns false if the connection should be closed.
+func (r *Remote) deliver(msg interface{}) bool {
+	switch Backpressure(atomic.LoadInt32((*int32)(&r.backpressure))) {
+	case DropOldest:
+		for {
+			select {
+			case r.Incoming <- msg:
+				return true
+			default:
+			}
+			select {
+			case <-r.Incoming:
+				atomic.AddUint64(&r.dropped, 1)
+			default:
+			}
+		}
+	case Disconnect:
+		select {
+		case r.Incoming <- msg:
+			return true
+		default:
+			atomic.AddUint64(&r.dropped, 1)
+			glog.Errorln("Incoming buffer full, disconnecting")
+			return false
+		}
+	default:
+		r.Incoming <- msg
+		return true
+	}
+}
+
+// TransactionFilter selects which transactions are passed to a handler
+type TransactionFilter func(*TransactionStreamMsg) bool
+
+// Matches transactions sent by, or whose metadata affects, any of the accounts
+func FilterAccounts(accounts ...data.Account) TransactionFilter {
+	return func(msg *TransactionStreamMsg) bool {
+		for _, account := range accounts {
+			if msg.Transaction.GetBase().Account == account || msg.Transaction.Affects(account) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func FilterTransactionTypes(types ...data.TransactionType) TransactionFilter {
+	return func(msg *TransactionStreamMsg) bool {
+		for _, typ := range types {
+			if msg.Transaction.GetTransactionType() == typ {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Matches engine results by name, ie. "tesSUCCESS"
+func FilterResults(results ...string) TransactionFilter {
+	return func(msg *TransactionStreamMsg) bool {
+		for _, result := range results {
+			if msg.EngineResult.String() == result {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type transactionHandler struct {
+	handler func(*TransactionStreamMsg)
+	filters []TransactionFilter
+}
+
+// All filters must match for the handler to be called
+func (h *transactionHandler) match(msg *TransactionStreamMsg) bool {
+	for _, filter := range h.filters {
+		if !filter(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+type handlers struct {
+	transaction []transactionHandler
+	ledger      []func(*LedgerStreamMsg)
+	server      []func(*ServerStreamMsg)
+	validation  []func(*ValidationStreamMsg)
+	manifest    []func(*ManifestStreamMsg)
+	consensus   []func(*ConsensusStreamMsg)
+	peerStatus  []func(*PeerStatusStreamMsg)
+	bookChanges []func(*BookChangesStreamMsg)
+	pathFind    []func(*PathFindCreateResult)
+	close       []func()
+}
+
+type dispatcher struct {
+	mu sync.RWMutex
+	handlers
+}
+
+// The handler slices are only ever appended to, so a copy taken under the
+// lock can be used after unlocking, letting handlers register handlers
+func (d *dispatcher) current() handlers {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.handlers
+}
+
+func (d *dispatcher) dispatch(msg interface{}) {
+	h := d.current()
+	switch msg := msg.(type) {
+	case *TransactionStreamMsg:
+		for i := range h.transaction {
+			if h.transaction[i].match(msg) {
+				h.transaction[i].handler(msg)
+			}
+		}
+	case *LedgerStreamMsg:
+		for _, handler := range h.ledger {
+			handler(msg)
+		}
+	case *ServerStreamMsg:
+		for _, handler := range h.server {
+			handler(msg)
+		}
+	case *ValidationStreamMsg:
+		for _, handler := range h.validation {
+			handler(msg)
+		}
+	case *ManifestStreamMsg:
+		for _, handler := range h.manifest {
+			handler(msg)
+		}
+	case *ConsensusStreamMsg:
+		for _, handler := range h.consensus {
+			handler(msg)
+		}
+	case *PeerStatusStreamMsg:
+		for _, handler := range h.peerStatus {
+			handler(msg)
+		}
+	case *BookChangesStreamMsg:
+		for _, handler := range h.bookChanges {
+			handler(msg)
+		}
+	case *PathFindCreateResult:
+		for _, handler := range h.pathFind {
+			handler(msg)
+		}
+	}
+}
+
+func (d *dispatcher) closed() {
+	for _, handler := range d.current().close {
+		handler()
+	}
+}
+
+// Consumes the Incoming channel and calls the matching handlers until the
+// connection closes.
+func (d *dispatcher) run(incoming <-chan interface{}) {
+	for msg := range incoming {
+		d.dispatch(msg)
+	}
+	d.closed()
+}
+
+// Registers a handler, starting the dispatcher on first use. Once any
+// handler is registered the dispatcher consumes the Incoming channel, so
+// it should not also be read directly.
+func (r *Remote) register(f func(d *dispatcher)) {
+	r.dispatchOnce.Do(func() {
+		go r.dispatcher.run(r.Incoming)
+	})
+	r.dispatcher.mu.Lock()
+	defer r.dispatcher.mu.Unlock()
+	f(&r.dispatcher)
+}
+
+// Calls handler for each streamed transaction matching all of the filters
+func (r *Remote) OnTransaction(handler func(*TransactionStreamMsg), filters ...TransactionFilter) {
+	r.register(func(d *dispatcher) {
+		d.transaction = append(d.transaction, transactionHandler{handler, filters})
+	})
+}
+
+func (r *Remote) OnLedgerClosed(handler func(*LedgerStreamMsg)) {
+	r.register(func(d *dispatcher) { d.ledger = append(d.ledger, handler) })
+}
+
+func (r *Remote) OnServerStatus(handler func(*ServerStreamMsg)) {
+	r.register(func(d *dispatcher) { d.server = append(d.server, handler) })
+}
+
+func (r *Remote) OnValidation(handler func(*ValidationStreamMsg)) {
+	r.register(func(d *dispatcher) { d.validation = append(d.validation, handler) })
+}
+
+func (r *Remote) OnManifest(handler func(*ManifestStreamMsg)) {
+	r.register(func(d *dispatcher) { d.manifest = append(d.manifest, handler) })
+}
+
+func (r *Remote) OnConsensus(handler func(*ConsensusStreamMsg)) {
+	r.register(func(d *dispatcher) { d.consensus = append(d.consensus, handler) })
+}
+
+func (r *Remote) OnPeerStatus(handler func(*PeerStatusStreamMsg)) {
+	r.register(func(d *dispatcher) { d.peerStatus = append(d.peerStatus, handler) })
+}
+
+func (r *Remote) OnBookChanges(handler func(*BookChangesStreamMsg)) {
+	r.register(func(d *dispatcher) { d.bookChanges = append(d.bookChanges, handler) })
+}
+
+func (r *Remote) OnPathFind(handler func(*PathFindCreateResult)) {
+	r.register(func(d *dispatcher) { d.pathFind = append(d.pathFind, handler) })
+}
+
+// Called once after the connection has closed and all messages have been dispatched
+func (r *Remote) OnClose(handler func()) {
+	r.register(func(d *dispatcher) { d.close = append(d.close, handler) })
+}
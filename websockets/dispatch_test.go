@@ -0,0 +1,116 @@
+package websockets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MessagesSuite) TestBackpressure(c *C) {
+	r := &Remote{Incoming: make(chan interface{}, 2)}
+	c.Assert(r.deliver(1), Equals, true)
+	c.Assert(r.deliver(2), Equals, true)
+
+	r.SetBackpressure(DropOldest)
+	c.Assert(r.deliver(3), Equals, true)
+	c.Assert(r.Dropped(), Equals, uint64(1))
+	c.Assert(<-r.Incoming, Equals, 2)
+	c.Assert(<-r.Incoming, Equals, 3)
+
+	r.SetBackpressure(Disconnect)
+	c.Assert(r.deliver(4), Equals, true)
+	c.Assert(r.deliver(5), Equals, true)
+	c.Assert(r.deliver(6), Equals, false)
+	c.Assert(r.Dropped(), Equals, uint64(2))
+}
+
+func (s *MessagesSuite) TestClosedByServer(c *C) {
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if ws, err := upgrader.Upgrade(w, req, nil); err == nil {
+			ws.Close()
+		}
+	}))
+	defer server.Close()
+	r, err := NewRemote("ws" + strings.TrimPrefix(server.URL, "http"))
+	c.Assert(err, IsNil)
+	for range r.Incoming {
+	}
+
+	// More commands than the outgoing buffer holds all fail
+	for i := 0; i < 20; i++ {
+		result := make(chan error)
+		go func() {
+			_, err := r.ServerInfo()
+			result <- err
+		}()
+		select {
+		case err := <-result:
+			c.Assert(err, ErrorMatches, ".*Connection Closed.*")
+		case <-time.After(5 * time.Second):
+			c.Fatal("command blocked after the connection closed")
+		}
+	}
+	r.Close()
+}
+
+func (s *MessagesSuite) TestDispatcher(c *C) {
+	var offers, cancels []*TransactionStreamMsg
+	var ledgers []*LedgerStreamMsg
+	account, err := data.NewAccountFromAddress("rPEZyTnSyQyXBCwMVYyaafSVPL8oMtfG6a")
+	c.Assert(err, IsNil)
+	other, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+
+	r := &Remote{Incoming: make(chan interface{}, 10)}
+	done := make(chan struct{})
+	r.OnTransaction(func(msg *TransactionStreamMsg) {
+		offers = append(offers, msg)
+	}, FilterAccounts(*account), FilterTransactionTypes(data.OFFER_CREATE), FilterResults("tesSUCCESS"))
+	r.OnTransaction(func(msg *TransactionStreamMsg) {
+		cancels = append(cancels, msg)
+	}, FilterTransactionTypes(data.OFFER_CANCEL))
+	r.OnTransaction(func(msg *TransactionStreamMsg) {
+		c.Error("unexpected transaction for account")
+	}, FilterAccounts(*other))
+	r.OnLedgerClosed(func(msg *LedgerStreamMsg) {
+		ledgers = append(ledgers, msg)
+	})
+	r.OnClose(func() { close(done) })
+
+	tx := &TransactionStreamMsg{}
+	readResponseFile(c, tx, "testdata/transactions_stream.json")
+	ledger := &LedgerStreamMsg{}
+	readResponseFile(c, ledger, "testdata/ledger_stream.json")
+	r.Incoming <- tx
+	r.Incoming <- ledger
+	r.Incoming <- &ServerStreamMsg{}
+	close(r.Incoming)
+	<-done
+
+	c.Assert(offers, HasLen, 1)
+	c.Assert(cancels, HasLen, 0)
+	c.Assert(ledgers, HasLen, 1)
+	c.Assert(ledgers[0].LedgerSequence, Equals, uint32(6959229))
+}
+
+func (s *MessagesSuite) TestRegisterFromHandler(c *C) {
+	var servers int
+	r := &Remote{Incoming: make(chan interface{}, 10)}
+	done := make(chan struct{})
+	r.OnLedgerClosed(func(msg *LedgerStreamMsg) {
+		r.OnServerStatus(func(msg *ServerStreamMsg) { servers++ })
+	})
+	r.OnClose(func() { close(done) })
+
+	r.Incoming <- &LedgerStreamMsg{}
+	r.Incoming <- &ServerStreamMsg{}
+	close(r.Incoming)
+	<-done
+	c.Assert(servers, Equals, 1)
+}
@@ -0,0 +1,295 @@
+package websockets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// https://xrpl.org/ledger_entry.html
+// Only one of the selectors should be set for each request.
+type LedgerEntryCommand struct {
+	*Command
+	LedgerIndex    interface{}          `json:"ledger_index,omitempty"`
+	Binary         bool                 `json:"binary"`
+	Index          *data.Hash256        `json:"index,omitempty"`
+	AccountRoot    *data.Account        `json:"account_root,omitempty"`
+	RippleState    *RippleStateSelector `json:"ripple_state,omitempty"`
+	Offer          *OfferSelector       `json:"offer,omitempty"`
+	Directory      *DirectorySelector   `json:"directory,omitempty"`
+	Escrow         *EscrowSelector      `json:"escrow,omitempty"`
+	Check          *data.Hash256        `json:"check,omitempty"`
+	PaymentChannel *data.Hash256        `json:"payment_channel,omitempty"`
+	Ticket         *TicketSelector      `json:"ticket,omitempty"`
+	AMM            *AMMSelector         `json:"amm,omitempty"`
+	Result         *LedgerEntryResult   `json:"result,omitempty"`
+}
+
+type RippleStateSelector struct {
+	Accounts [2]data.Account `json:"accounts"`
+	Currency data.Currency   `json:"currency"`
+}
+
+type OfferSelector struct {
+	Account  data.Account `json:"account"`
+	Sequence uint32       `json:"seq"`
+}
+
+type DirectorySelector struct {
+	Root     data.Hash256 `json:"dir_root"`
+	SubIndex uint64       `json:"sub_index,omitempty"`
+}
+
+type EscrowSelector struct {
+	Owner    data.Account `json:"owner"`
+	Sequence uint32       `json:"seq"`
+}
+
+type TicketSelector struct {
+	Account        data.Account `json:"account"`
+	TicketSequence uint32       `json:"ticket_seq"`
+}
+
+type AMMSelector struct {
+	Asset  data.Asset `json:"asset"`
+	Asset2 data.Asset `json:"asset2"`
+}
+
+type LedgerEntryResult struct {
+	Index              data.Hash256     `json:"index"`
+	LedgerSequence     uint32           `json:"ledger_index"`
+	LedgerCurrentIndex uint32           `json:"ledger_current_index"`
+	LedgerHash         *data.Hash256    `json:"ledger_hash,omitempty"`
+	NodeBinary         string           `json:"node_binary"`
+	Validated          bool             `json:"validated"`
+	LedgerEntry        data.LedgerEntry `json:"-"`
+}
+
+func newLedgerEntryCommand(ledger interface{}) *LedgerEntryCommand {
+	return &LedgerEntryCommand{
+		Command:     newCommand("ledger_entry"),
+		LedgerIndex: ledger,
+		Binary:      true,
+	}
+}
+
+// Decodes the binary node and checks the returned index matches the expected one
+func readLedgerEntryResult(result *LedgerEntryResult, expected data.Hash256) (data.LedgerEntry, error) {
+	if result.Index != expected {
+		return nil, fmt.Errorf("ledger_entry: index mismatch: expected %s got %s", expected, result.Index)
+	}
+	b, err := hex.DecodeString(result.NodeBinary)
+	if err != nil {
+		return nil, err
+	}
+	// ReadLedgerEntry expects the index to be suffixed
+	le, err := data.ReadLedgerEntry(bytes.NewReader(append(b, result.Index[:]...)), data.Hash256{})
+	if err != nil {
+		return nil, err
+	}
+	return le, nil
+}
+
+func (r *requester) ledgerEntry(cmd *LedgerEntryCommand, expected data.Hash256) (data.LedgerEntry, error) {
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	le, err := readLedgerEntryResult(cmd.Result, expected)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Result.LedgerEntry = le
+	return le, nil
+}
+
+// Synchronously gets a single ledger entry by its index
+func (r *requester) LedgerEntry(index data.Hash256, ledger interface{}) (*LedgerEntryResult, error) {
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Index = &index
+	if _, err := r.ledgerEntry(cmd, index); err != nil {
+		return nil, err
+	}
+	return cmd.Result, nil
+}
+
+func (r *requester) AccountRootEntry(account data.Account, ledger interface{}) (*data.AccountRoot, error) {
+	index, err := data.GetAccountRootIndex(account)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.AccountRoot = &account
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if accountRoot, ok := le.(*data.AccountRoot); ok {
+		return accountRoot, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+func (r *requester) RippleStateEntry(a, b data.Account, currency data.Currency, ledger interface{}) (*data.RippleState, error) {
+	index, err := data.GetRippleStateIndex(a, b, currency)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.RippleState = &RippleStateSelector{
+		Accounts: [2]data.Account{a, b},
+		Currency: currency,
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if rippleState, ok := le.(*data.RippleState); ok {
+		return rippleState, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+func (r *requester) OfferEntry(account data.Account, sequence uint32, ledger interface{}) (*data.Offer, error) {
+	index, err := data.GetOfferIndex(account, sequence)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Offer = &OfferSelector{
+		Account:  account,
+		Sequence: sequence,
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if offer, ok := le.(*data.Offer); ok {
+		return offer, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+// Gets a page of a directory. Use data.GetOwnerDirectoryIndex for the root of an account's owner directory.
+func (r *requester) DirectoryEntry(root data.Hash256, page uint64, ledger interface{}) (*data.Directory, error) {
+	var subIndex *data.NodeIndex
+	if page > 0 {
+		subIndex = (*data.NodeIndex)(&page)
+	}
+	index, err := data.GetDirectoryNodeIndex(root, subIndex)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Directory = &DirectorySelector{
+		Root:     root,
+		SubIndex: page,
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if directory, ok := le.(*data.Directory); ok {
+		return directory, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+func (r *requester) EscrowEntry(owner data.Account, sequence uint32, ledger interface{}) (*data.Escrow, error) {
+	index, err := data.GetEscrowIndex(owner, sequence)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Escrow = &EscrowSelector{
+		Owner:    owner,
+		Sequence: sequence,
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if escrow, ok := le.(*data.Escrow); ok {
+		return escrow, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+// rippled only accepts the check's index, so it is computed from the creating account and sequence
+func (r *requester) CheckEntry(account data.Account, sequence uint32, ledger interface{}) (*data.Check, error) {
+	index, err := data.GetCheckIndex(account, sequence)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Check = index
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if check, ok := le.(*data.Check); ok {
+		return check, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+// rippled only accepts the channel's index, so it is computed from the source, destination and sequence
+func (r *requester) PaymentChannelEntry(account, destination data.Account, sequence uint32, ledger interface{}) (*data.PayChannel, error) {
+	index, err := data.GetPaymentChannelIndex(account, destination, sequence)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.PaymentChannel = index
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if channel, ok := le.(*data.PayChannel); ok {
+		return channel, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+func (r *requester) TicketEntry(account data.Account, ticketSequence uint32, ledger interface{}) (*data.Ticket, error) {
+	index, err := data.GetTicketIndex(account, ticketSequence)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.Ticket = &TicketSelector{
+		Account:        account,
+		TicketSequence: ticketSequence,
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if ticket, ok := le.(*data.Ticket); ok {
+		return ticket, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
+
+func (r *requester) AMMEntry(asset, asset2 data.Issue, ledger interface{}) (*data.AMM, error) {
+	index, err := data.GetAMMIndex(asset, asset2)
+	if err != nil {
+		return nil, err
+	}
+	cmd := newLedgerEntryCommand(ledger)
+	cmd.AMM = &AMMSelector{
+		Asset:  *asset.Asset(),
+		Asset2: *asset2.Asset(),
+	}
+	le, err := r.ledgerEntry(cmd, *index)
+	if err != nil {
+		return nil, err
+	}
+	if amm, ok := le.(*data.AMM); ok {
+		return amm, nil
+	}
+	return nil, fmt.Errorf("ledger_entry: unexpected %s", le.GetType())
+}
@@ -0,0 +1,59 @@
+package websockets
+
+import (
+	"encoding/json"
+
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MessagesSuite) TestLedgerEntryResponse(c *C) {
+	msg := &LedgerEntryCommand{}
+	readResponseFile(c, msg, "testdata/ledger_entry.json")
+
+	// Response fields
+	c.Assert(msg.Status, Equals, "success")
+	c.Assert(msg.Type, Equals, "response")
+
+	// Result fields
+	c.Assert(msg.Result.LedgerSequence, Equals, uint32(7636529))
+	c.Assert(msg.Result.Validated, Equals, true)
+
+	account, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	index, err := data.GetAccountRootIndex(*account)
+	c.Assert(err, IsNil)
+	le, err := readLedgerEntryResult(msg.Result, *index)
+	c.Assert(err, IsNil)
+	accountRoot, ok := le.(*data.AccountRoot)
+	c.Assert(ok, Equals, true)
+	c.Assert(accountRoot.Account.String(), Equals, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(*accountRoot.Sequence, Equals, uint32(546))
+	c.Assert(*accountRoot.TransferRate, Equals, uint32(1002000000))
+	c.Assert(accountRoot.Balance.String(), Equals, "10321199.422233")
+
+	// A different keylet must be rejected
+	other, err := data.GetOfferIndex(*account, 546)
+	c.Assert(err, IsNil)
+	_, err = readLedgerEntryResult(msg.Result, *other)
+	c.Assert(err, NotNil)
+}
+
+func (s *MessagesSuite) TestLedgerEntryCommand(c *C) {
+	account, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	cmd := newLedgerEntryCommand("validated")
+	cmd.Ticket = &TicketSelector{
+		Account:        *account,
+		TicketSequence: 10,
+	}
+	b, err := json.Marshal(cmd)
+	c.Assert(err, IsNil)
+	var fields map[string]interface{}
+	c.Assert(json.Unmarshal(b, &fields), IsNil)
+	c.Assert(fields["command"], Equals, "ledger_entry")
+	c.Assert(fields["binary"], Equals, true)
+	c.Assert(fields["ledger_index"], Equals, "validated")
+	c.Assert(fields["ticket"], DeepEquals, map[string]interface{}{"account": "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "ticket_seq": 10.0})
+	c.Assert(fields["index"], IsNil)
+}
@@ -46,7 +46,7 @@ func (r *Remote) PathFindCreate(src, dest data.Account, amt data.Amount, sendMax
 		SendMax:            sendMax,
 		SourceCurrencies:   sourceCurrencies,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
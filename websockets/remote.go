@@ -32,10 +32,30 @@ const (
 	dialTimeout = 5 * time.Second
 )
 
+// Remote is a WebSocket session with a rippled server. It must be made by
+// NewRemote, as the zero value is not connected.
 type Remote struct {
-	Incoming chan interface{}
-	outgoing chan Syncer
-	ws       *websocket.Conn
+	dropped uint64 // first for 64-bit atomic alignment
+	requester
+	Incoming     chan interface{}
+	outgoing     chan Syncer
+	done         chan struct{} // closed when run returns
+	ws           *websocket.Conn
+	mu           sync.Mutex
+	waiters      []*ledgerWaiter
+	ledger       *LedgerStreamMsg // latest ledger seen by the waiters
+	subscribed   bool             // to the ledger stream for the waiters
+	streamLedger bool             // deliver ledger messages to Incoming
+	closed       bool
+	backpressure Backpressure
+	dispatcher   dispatcher
+	dispatchOnce sync.Once
+}
+
+type ledgerWaiter struct {
+	sequence uint32
+	c        chan *LedgerStreamMsg
+	err      error // set before c is closed
 }
 
 // NewRemote returns a new remote session connected to the specified
@@ -57,8 +77,10 @@ func NewRemote(endpoint string) (*Remote, error) {
 	r := &Remote{
 		Incoming: make(chan interface{}, 1000),
 		outgoing: make(chan Syncer, 10),
+		done:     make(chan struct{}),
 		ws:       ws,
 	}
+	r.requester.sender = r.enqueue
 
 	go r.run()
 	return r, nil
@@ -76,6 +98,15 @@ func (r *Remote) Close() {
 	}
 }
 
+// Queues a command for run, or fails it once the connection has closed
+func (r *Remote) enqueue(cmd Syncer) {
+	select {
+	case r.outgoing <- cmd:
+	case <-r.done:
+		go cmd.Fail("Connection Closed")
+	}
+}
+
 // run spawns the read/write pumps and then runs until Close() is called.
 func (r *Remote) run() {
 	outbound := make(chan interface{})
@@ -85,11 +116,22 @@ func (r *Remote) run() {
 	defer func() {
 		close(outbound) // Shuts down the writePump
 		close(r.Incoming)
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+		r.failWaiters(fmt.Errorf("Connection Closed"))
 
 		// Cancel all pending commands with an error
 		for _, c := range pending {
 			c.Fail("Connection Closed")
 		}
+		// and those queued now or later, until Close is called
+		close(r.done)
+		go func() {
+			for c := range r.outgoing {
+				c.Fail("Connection Closed")
+			}
+		}()
 
 		// Drain the inbound channel and block until it is closed,
 		// indicating that the readPump has returned.
@@ -137,7 +179,14 @@ func (r *Remote) run() {
 					glog.Errorln(err.Error(), string(in))
 					continue
 				}
-				r.Incoming <- cmd
+				if ledger, ok := cmd.(*LedgerStreamMsg); ok {
+					if !r.notifyWaiters(ledger) {
+						continue
+					}
+				}
+				if !r.deliver(cmd) {
+					return
+				}
 				continue
 			}
 
@@ -157,13 +206,93 @@ func (r *Remote) run() {
 	}
 }
 
+// Returns true if the ledger message should also be delivered to Incoming
+func (r *Remote) notifyWaiters(ledger *LedgerStreamMsg) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ledger != nil && r.ledger.LedgerSequence >= ledger.LedgerSequence {
+		return r.streamLedger
+	}
+	r.ledger = ledger
+	remaining := r.waiters[:0]
+	for _, w := range r.waiters {
+		if ledger.LedgerSequence >= w.sequence {
+			w.c <- ledger
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	r.waiters = remaining
+	return r.streamLedger
+}
+
+// Closes all waiters with err. The next waiter will subscribe again.
+func (r *Remote) failWaiters(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.waiters {
+		w.err = err
+		close(w.c)
+	}
+	r.waiters = nil
+	r.subscribed = false
+}
+
+// WaitForLedger blocks until a ledger with at least the specified sequence
+// has closed. The first call subscribes to the ledger stream, which then
+// serves all waiters. Ledger messages are only delivered to the Incoming
+// channel if the ledger stream is also subscribed to with Subscribe.
+func (r *Remote) WaitForLedger(sequence uint32) (*LedgerStreamMsg, error) {
+	waiter := &ledgerWaiter{
+		sequence: sequence,
+		c:        make(chan *LedgerStreamMsg, 1),
+	}
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("Connection Closed")
+	}
+	if r.ledger != nil && r.ledger.LedgerSequence >= sequence {
+		ledger := r.ledger
+		r.mu.Unlock()
+		return ledger, nil
+	}
+	r.waiters = append(r.waiters, waiter)
+	subscribe := !r.subscribed
+	r.subscribed = true
+	r.mu.Unlock()
+
+	if subscribe {
+		result, err := r.subscribe(SubscribeRequest{Streams: []string{StreamLedger}})
+		if err != nil {
+			r.failWaiters(err)
+		} else {
+			r.notifyWaiters(result.LedgerStreamMsg)
+		}
+	}
+	ledger, ok := <-waiter.c
+	if !ok {
+		return nil, waiter.err
+	}
+	return ledger, nil
+}
+
+// Waits for the ledger after the latest one seen on the ledger stream
+func (r *Remote) waitForNextLedger() (*LedgerStreamMsg, error) {
+	latest, err := r.WaitForLedger(0)
+	if err != nil {
+		return nil, err
+	}
+	return r.WaitForLedger(latest.LedgerSequence + 1)
+}
+
 // Synchronously get a single transaction
-func (r *Remote) Tx(hash data.Hash256) (*TxResult, error) {
+func (r *requester) Tx(hash data.Hash256) (*TxResult, error) {
 	cmd := &TxCommand{
 		Command:     newCommand("tx"),
 		Transaction: hash,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -171,11 +300,11 @@ func (r *Remote) Tx(hash data.Hash256) (*TxResult, error) {
 	return cmd.Result, nil
 }
 
-func (r *Remote) accountTx(account data.Account, c chan *data.TransactionWithMetaData, pageSize int, minLedger, maxLedger int64) {
+func (r *requester) accountTx(account data.Account, c chan *data.TransactionWithMetaData, pageSize int, minLedger, maxLedger int64) {
 	defer close(c)
 	cmd := newAccountTxCommand(account, pageSize, nil, minLedger, maxLedger)
 	for ; ; cmd = newAccountTxCommand(account, pageSize, cmd.Result.Marker, minLedger, maxLedger) {
-		r.outgoing <- cmd
+		r.send(cmd)
 		<-cmd.Ready
 		if cmd.CommandError != nil {
 			glog.Errorln(cmd.Error())
@@ -198,14 +327,14 @@ func (r *Remote) accountTx(account data.Account, c chan *data.TransactionWithMet
 //
 // Use minLedger -1 for the earliest ledger available.
 // Use maxLedger -1 for the most recent validated ledger.
-func (r *Remote) AccountTx(account data.Account, pageSize int, minLedger, maxLedger int64) chan *data.TransactionWithMetaData {
+func (r *requester) AccountTx(account data.Account, pageSize int, minLedger, maxLedger int64) chan *data.TransactionWithMetaData {
 	c := make(chan *data.TransactionWithMetaData)
 	go r.accountTx(account, c, pageSize, minLedger, maxLedger)
 	return c
 }
 
 // Synchronously submit a single transaction
-func (r *Remote) Submit(tx data.Transaction) (*SubmitResult, error) {
+func (r *requester) Submit(tx data.Transaction) (*SubmitResult, error) {
 	_, raw, err := data.Raw(tx)
 	if err != nil {
 		return nil, err
@@ -214,7 +343,7 @@ func (r *Remote) Submit(tx data.Transaction) (*SubmitResult, error) {
 		Command: newCommand("submit"),
 		TxBlob:  fmt.Sprintf("%X", raw),
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -222,8 +351,9 @@ func (r *Remote) Submit(tx data.Transaction) (*SubmitResult, error) {
 	return cmd.Result, nil
 }
 
-// Synchronously submit multiple transactions
-func (r *Remote) SubmitBatch(txs []data.Transaction) ([]*SubmitResult, error) {
+// Synchronously submit multiple transactions. Results are nil for any
+// command which failed, and the first failure is returned as the error.
+func (r *requester) SubmitBatch(txs []data.Transaction) ([]*SubmitResult, error) {
 	commands := make([]*SubmitCommand, len(txs))
 	results := make([]*SubmitResult, len(txs))
 	for i := range txs {
@@ -235,24 +365,28 @@ func (r *Remote) SubmitBatch(txs []data.Transaction) ([]*SubmitResult, error) {
 			Command: newCommand("submit"),
 			TxBlob:  fmt.Sprintf("%X", raw),
 		}
-		r.outgoing <- cmd
+		r.send(cmd)
 		commands[i] = cmd
 	}
+	var err error
 	for i := range commands {
 		<-commands[i].Ready
 		results[i] = commands[i].Result
+		if commands[i].CommandError != nil && err == nil {
+			err = fmt.Errorf("submit %d: %s", i, commands[i].CommandError.Error())
+		}
 	}
-	return results, nil
+	return results, err
 }
 
 // Synchronously gets ledger entries
-func (r *Remote) LedgerData(ledger interface{}, marker *data.Hash256) (*LedgerDataResult, error) {
+func (r *requester) LedgerData(ledger interface{}, marker *data.Hash256) (*LedgerDataResult, error) {
 	cmd := &LedgerDataCommand{
 		Command: newCommand("ledger_data"),
 		Ledger:  ledger,
 		Marker:  marker,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -260,7 +394,7 @@ func (r *Remote) LedgerData(ledger interface{}, marker *data.Hash256) (*LedgerDa
 	return cmd.Result, nil
 }
 
-func (r *Remote) streamLedgerData(ledger interface{}, start, end string, c chan data.LedgerEntrySlice, wg *sync.WaitGroup) {
+func (r *requester) streamLedgerData(ledger interface{}, start, end string, c chan data.LedgerEntrySlice, wg *sync.WaitGroup) {
 	defer wg.Done()
 	first, err := data.NewHash256(start)
 	if err != nil {
@@ -269,7 +403,7 @@ func (r *Remote) streamLedgerData(ledger interface{}, start, end string, c chan
 	cmd := newBinaryLedgerDataCommand(ledger, first)
 	var br bytes.Reader
 	for ; ; cmd = newBinaryLedgerDataCommand(ledger, cmd.Result.Marker) {
-		r.outgoing <- cmd
+		r.send(cmd)
 		<-cmd.Ready
 		if cmd.CommandError != nil {
 			glog.Errorln(cmd.Error())
@@ -304,7 +438,7 @@ func (r *Remote) streamLedgerData(ledger interface{}, start, end string, c chan
 }
 
 // Asynchronously retrieve all data for a ledger using the binary form
-func (r *Remote) StreamLedgerData(ledger interface{}) chan data.LedgerEntrySlice {
+func (r *requester) StreamLedgerData(ledger interface{}) chan data.LedgerEntrySlice {
 	c := make(chan data.LedgerEntrySlice, 100)
 	wg := &sync.WaitGroup{}
 	for i := 0; i < 16; i++ {
@@ -321,14 +455,14 @@ func (r *Remote) StreamLedgerData(ledger interface{}) chan data.LedgerEntrySlice
 }
 
 // Synchronously gets a single ledger
-func (r *Remote) Ledger(ledger interface{}, transactions bool) (*LedgerResult, error) {
+func (r *requester) Ledger(ledger interface{}, transactions bool) (*LedgerResult, error) {
 	cmd := &LedgerCommand{
 		Command:      newCommand("ledger"),
 		LedgerIndex:  ledger,
 		Transactions: transactions,
 		Expand:       true,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -337,12 +471,12 @@ func (r *Remote) Ledger(ledger interface{}, transactions bool) (*LedgerResult, e
 	return cmd.Result, nil
 }
 
-func (r *Remote) LedgerHeader(ledger interface{}) (*LedgerHeaderResult, error) {
+func (r *requester) LedgerHeader(ledger interface{}) (*LedgerHeaderResult, error) {
 	cmd := &LedgerHeaderCommand{
 		Command: newCommand("ledger_header"),
 		Ledger:  ledger,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -351,7 +485,7 @@ func (r *Remote) LedgerHeader(ledger interface{}) (*LedgerHeaderResult, error) {
 }
 
 // Synchronously requests paths
-func (r *Remote) RipplePathFind(src, dest data.Account, amount data.Amount, srcCurr *[]data.Currency) (*RipplePathFindResult, error) {
+func (r *requester) RipplePathFind(src, dest data.Account, amount data.Amount, srcCurr *[]data.Currency) (*RipplePathFindResult, error) {
 	cmd := &RipplePathFindCommand{
 		Command:       newCommand("ripple_path_find"),
 		SrcAccount:    src,
@@ -359,7 +493,7 @@ func (r *Remote) RipplePathFind(src, dest data.Account, amount data.Amount, srcC
 		DestAccount:   dest,
 		DestAmount:    amount,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -368,12 +502,12 @@ func (r *Remote) RipplePathFind(src, dest data.Account, amount data.Amount, srcC
 }
 
 // Synchronously requests account info
-func (r *Remote) AccountInfo(a data.Account) (*AccountInfoResult, error) {
+func (r *requester) AccountInfo(a data.Account) (*AccountInfoResult, error) {
 	cmd := &AccountInfoCommand{
 		Command: newCommand("account_info"),
 		Account: a,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -382,7 +516,7 @@ func (r *Remote) AccountInfo(a data.Account) (*AccountInfoResult, error) {
 }
 
 // Synchronously requests account line info
-func (r *Remote) AccountLines(account data.Account, ledgerIndex interface{}) (*AccountLinesResult, error) {
+func (r *requester) AccountLines(account data.Account, ledgerIndex interface{}) (*AccountLinesResult, error) {
 	var (
 		lines  data.AccountLineSlice
 		marker *data.Hash256
@@ -395,7 +529,7 @@ func (r *Remote) AccountLines(account data.Account, ledgerIndex interface{}) (*A
 			Marker:      marker,
 			LedgerIndex: ledgerIndex,
 		}
-		r.outgoing <- cmd
+		r.send(cmd)
 		<-cmd.Ready
 		switch {
 		case cmd.CommandError != nil:
@@ -414,8 +548,42 @@ func (r *Remote) AccountLines(account data.Account, ledgerIndex interface{}) (*A
 	}
 }
 
+// Synchronously requests the ledger entries owned by an account, optionally
+// restricted to one type such as "ticket" or "check"
+func (r *requester) AccountObjects(account data.Account, typ string, ledgerIndex interface{}) (*AccountObjectsResult, error) {
+	var (
+		objects data.LedgerEntrySlice
+		marker  interface{}
+	)
+	for {
+		cmd := &AccountObjectsCommand{
+			Command:     newCommand("account_objects"),
+			Account:     account,
+			Type:        typ,
+			Limit:       400,
+			Marker:      marker,
+			LedgerIndex: ledgerIndex,
+		}
+		r.send(cmd)
+		<-cmd.Ready
+		switch {
+		case cmd.CommandError != nil:
+			return nil, cmd.CommandError
+		case cmd.Result.Marker != nil:
+			objects = append(objects, cmd.Result.AccountObjects...)
+			marker = cmd.Result.Marker
+			if cmd.Result.LedgerSequence != nil {
+				ledgerIndex = *cmd.Result.LedgerSequence
+			}
+		default:
+			cmd.Result.AccountObjects = append(objects, cmd.Result.AccountObjects...)
+			return cmd.Result, nil
+		}
+	}
+}
+
 // Synchronously requests account offers
-func (r *Remote) AccountOffers(account data.Account, ledgerIndex interface{}) (*AccountOffersResult, error) {
+func (r *requester) AccountOffers(account data.Account, ledgerIndex interface{}) (*AccountOffersResult, error) {
 	var (
 		offers data.AccountOfferSlice
 		marker *data.Hash256
@@ -428,7 +596,7 @@ func (r *Remote) AccountOffers(account data.Account, ledgerIndex interface{}) (*
 			Marker:      marker,
 			LedgerIndex: ledgerIndex,
 		}
-		r.outgoing <- cmd
+		r.send(cmd)
 		<-cmd.Ready
 		switch {
 		case cmd.CommandError != nil:
@@ -447,7 +615,7 @@ func (r *Remote) AccountOffers(account data.Account, ledgerIndex interface{}) (*
 	}
 }
 
-func (r *Remote) BookOffers(taker data.Account, ledgerIndex interface{}, pays, gets data.Asset) (*BookOffersResult, error) {
+func (r *requester) BookOffers(taker data.Account, ledgerIndex interface{}, pays, gets data.Asset) (*BookOffersResult, error) {
 	cmd := &BookOffersCommand{
 		Command:     newCommand("book_offers"),
 		LedgerIndex: ledgerIndex,
@@ -456,7 +624,7 @@ func (r *Remote) BookOffers(taker data.Account, ledgerIndex interface{}, pays, g
 		TakerGets:   gets,
 		Limit:       5000, // Marker not implemented....
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -469,62 +637,103 @@ func (r *Remote) BookOffers(taker data.Account, ledgerIndex interface{}, pays, g
 func (r *Remote) Subscribe(ledger, transactions, transactionsProposed, server bool) (*SubscribeResult, error) {
 	streams := []string{}
 	if ledger {
-		streams = append(streams, "ledger")
+		streams = append(streams, StreamLedger)
 	}
 	if transactions {
-		streams = append(streams, "transactions")
+		streams = append(streams, StreamTransactions)
 	}
 	if transactionsProposed {
-		streams = append(streams, "transactions_proposed")
+		streams = append(streams, StreamTransactionsProposed)
 	}
 	if server {
-		streams = append(streams, "server")
+		streams = append(streams, StreamServer)
 	}
+	return r.SubscribeTo(SubscribeRequest{Streams: streams})
+}
+
+type OrderBookSubscription struct {
+	TakerGets data.Asset `json:"taker_gets"`
+	TakerPays data.Asset `json:"taker_pays"`
+	Snapshot  bool       `json:"snapshot"`
+	Both      bool       `json:"both"`
+}
+
+func (r *Remote) SubscribeOrderBooks(books []OrderBookSubscription) (*SubscribeResult, error) {
+	return r.SubscribeTo(SubscribeRequest{
+		Streams: []string{StreamLedger, StreamServer},
+		Books:   books,
+	})
+}
+
+// Synchronously subscribe to any combination of streams, accounts and books.
+// Messages are received asynchronously over the Incoming channel
+func (r *Remote) SubscribeTo(req SubscribeRequest) (*SubscribeResult, error) {
+	if req.hasStream(StreamLedger) {
+		r.mu.Lock()
+		r.streamLedger = true
+		r.mu.Unlock()
+	}
+	return r.subscribe(req)
+}
+
+func (r *Remote) subscribe(req SubscribeRequest) (*SubscribeResult, error) {
 	cmd := &SubscribeCommand{
-		Command: newCommand("subscribe"),
-		Streams: streams,
+		Command:          newCommand("subscribe"),
+		SubscribeRequest: req,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
 	}
 
-	if ledger && cmd.Result.LedgerStreamMsg == nil {
+	if req.hasStream(StreamLedger) && cmd.Result.LedgerStreamMsg == nil {
 		return nil, fmt.Errorf("Missing ledger subscribe response")
 	}
-	if server && cmd.Result.ServerStreamMsg == nil {
+	if req.hasStream(StreamServer) && cmd.Result.ServerStreamMsg == nil {
 		return nil, fmt.Errorf("Missing server subscribe response")
 	}
 	return cmd.Result, nil
 }
 
-type OrderBookSubscription struct {
-	TakerGets data.Asset `json:"taker_gets"`
-	TakerPays data.Asset `json:"taker_pays"`
-	Snapshot  bool       `json:"snapshot"`
-	Both      bool       `json:"both"`
-}
-
-func (r *Remote) SubscribeOrderBooks(books []OrderBookSubscription) (*SubscribeResult, error) {
-	cmd := &SubscribeCommand{
-		Command: newCommand("subscribe"),
-		Streams: []string{"ledger", "server"},
-		Books:   books,
+// Synchronously stop receiving the requested streams, accounts and books.
+// The ledger stream stays subscribed while WaitForLedger uses it, but its
+// messages are no longer delivered to the Incoming channel.
+func (r *Remote) Unsubscribe(req SubscribeRequest) error {
+	if req.hasStream(StreamLedger) {
+		r.mu.Lock()
+		r.streamLedger = false
+		if r.subscribed {
+			streams := make([]string, 0, len(req.Streams))
+			for _, stream := range req.Streams {
+				if stream != StreamLedger {
+					streams = append(streams, stream)
+				}
+			}
+			req.Streams = streams
+		}
+		r.mu.Unlock()
+		if len(req.Streams)+len(req.Accounts)+len(req.AccountsProposed)+len(req.Books) == 0 {
+			return nil
+		}
+	}
+	cmd := &UnsubscribeCommand{
+		Command:          newCommand("unsubscribe"),
+		SubscribeRequest: req,
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
-		return nil, cmd.CommandError
+		return cmd.CommandError
 	}
-	return cmd.Result, nil
+	return nil
 }
 
-func (r *Remote) Fee() (*FeeResult, error) {
+func (r *requester) Fee() (*FeeResult, error) {
 	cmd := &FeeCommand{
 		Command: newCommand("fee"),
 	}
-	r.outgoing <- cmd
+	r.send(cmd)
 	<-cmd.Ready
 	if cmd.CommandError != nil {
 		return nil, cmd.CommandError
@@ -0,0 +1,116 @@
+package websockets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// RPCClient sends commands to rippled's HTTP JSON-RPC port using the same
+// Command and Result types as Remote.
+type RPCClient struct {
+	requester
+	endpoint string
+	client   *http.Client
+}
+
+// NewRPCClient returns a client for the JSON-RPC endpoint, ie.
+// http://localhost:5005. If client is nil, http.DefaultClient is used.
+func NewRPCClient(endpoint string, client *http.Client) *RPCClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := &RPCClient{
+		endpoint: endpoint,
+		client:   client,
+	}
+	c.requester.sender = func(cmd Syncer) { go c.post(cmd) }
+	return c
+}
+
+func (c *RPCClient) post(cmd Syncer) {
+	if err := c.roundTrip(cmd); err != nil {
+		cmd.Fail(err.Error())
+		return
+	}
+	cmd.Done()
+}
+
+func (c *RPCClient) roundTrip(cmd Syncer) error {
+	body, err := newRPCRequest(cmd)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", c.endpoint, resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	envelope, err := readRPCResponse(b)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope, cmd)
+}
+
+// Moves the command's fields into the single params object expected by
+// JSON-RPC, ie. {"method":"account_info","params":[{"account":"r..."}]}
+func newRPCRequest(cmd Syncer) ([]byte, error) {
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]json.RawMessage
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+	method := params["command"]
+	delete(params, "command")
+	delete(params, "id")
+	return json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": []interface{}{params},
+	})
+}
+
+// Rewrites a JSON-RPC response into the WebSocket form so that it can be
+// unmarshalled into a command. The status and any error fields are
+// hoisted out of the result, which is dropped on error.
+func readRPCResponse(b []byte) ([]byte, error) {
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Result) == 0 {
+		return nil, fmt.Errorf("JSON-RPC response missing result")
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, err
+	}
+	envelope := map[string]json.RawMessage{
+		"type":   json.RawMessage(`"response"`),
+		"status": result["status"],
+	}
+	if _, ok := result["error"]; ok {
+		for _, field := range []string{"error", "error_code", "error_message", "error_exception"} {
+			if value, ok := result[field]; ok {
+				envelope[field] = value
+			}
+		}
+	} else {
+		envelope["result"] = response.Result
+	}
+	return json.Marshal(envelope)
+}
@@ -0,0 +1,143 @@
+package websockets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+type RPCSuite struct {
+	server   *httptest.Server
+	requests []map[string]interface{}
+}
+
+var _ = Suite(&RPCSuite{})
+
+// Serves the "result" of the WebSocket fixture for each method in JSON-RPC form
+var rpcFixtures = map[string]string{
+	"account_info": "testdata/account_info.json",
+	"tx":           "testdata/tx.json",
+	"server_info":  "testdata/server_info.json",
+	"simulate":     "testdata/simulate.json",
+}
+
+func (s *RPCSuite) SetUpTest(c *C) {
+	s.requests = nil
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+}
+
+func (s *RPCSuite) serve(w http.ResponseWriter, req *http.Request) {
+	var request struct {
+		Method string
+		Params []map[string]interface{}
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil || len(request.Params) != 1 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	s.requests = append(s.requests, request.Params[0])
+	path, ok := rpcFixtures[request.Method]
+	if !ok {
+		fmt.Fprintf(w, `{"result":{"error":"unknownCmd","error_code":32,"error_message":"Unknown method.","status":"error"}}`)
+		return
+	}
+	var fixture struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(b, &fixture)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fixture.Result["status"] = "success"
+	json.NewEncoder(w).Encode(fixture)
+}
+
+func (s *RPCSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+func (s *RPCSuite) TestAccountInfo(c *C) {
+	account, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	var client Client = NewRPCClient(s.server.URL, nil)
+	result, err := client.AccountInfo(*account)
+	c.Assert(err, IsNil)
+	c.Assert(result.LedgerSequence, Equals, uint32(7636529))
+	c.Assert(*result.AccountData.Sequence, Equals, uint32(546))
+	c.Assert(result.AccountData.Balance.String(), Equals, "10321199.422233")
+
+	c.Assert(s.requests, HasLen, 1)
+	c.Assert(s.requests[0]["account"], Equals, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	_, ok := s.requests[0]["id"]
+	c.Assert(ok, Equals, false)
+	_, ok = s.requests[0]["command"]
+	c.Assert(ok, Equals, false)
+}
+
+func (s *RPCSuite) TestTx(c *C) {
+	hash, err := data.NewHash256("2D0CE11154B655A2BFE7F3F857AAC344622EC7DAB11B1EBD920DCDB00E8646FF")
+	c.Assert(err, IsNil)
+	result, err := NewRPCClient(s.server.URL, nil).Tx(*hash)
+	c.Assert(err, IsNil)
+	c.Assert(result.Validated, Equals, true)
+	c.Assert(result.GetHash().String(), Equals, hash.String())
+	c.Assert(result.MetaData.TransactionResult.String(), Equals, "tesSUCCESS")
+}
+
+func (s *RPCSuite) TestServerInfo(c *C) {
+	result, err := NewRPCClient(s.server.URL, nil).ServerInfo()
+	c.Assert(err, IsNil)
+	c.Assert(result.Info.ServerState, Not(Equals), "")
+}
+
+func (s *RPCSuite) TestSimulateSigned(c *C) {
+	sign := &SignCommand{}
+	readResponseFile(c, sign, "testdata/sign.json")
+	tx, err := readTxBlob(sign.Result.TxBlob)
+	c.Assert(err, IsNil)
+	result, err := NewRPCClient(s.server.URL, nil).Simulate(tx)
+	c.Assert(err, IsNil)
+	c.Assert(result.TransactionWithMetaData.GetHash().String(), Equals, simulateHash)
+
+	// Only a copy is stripped of its signature
+	c.Assert(s.requests, HasLen, 1)
+	sent, err := readTxBlob(s.requests[0]["tx_blob"].(string))
+	c.Assert(err, IsNil)
+	c.Assert(sent.GetBase().TxnSignature, IsNil)
+	c.Assert(sent.GetBase().SigningPubKey.IsZero(), Equals, true)
+	c.Assert(sent.GetHash().String(), Equals, simulateHash)
+	c.Assert(tx.GetBase().TxnSignature, NotNil)
+	c.Assert(tx.GetHash().String(), Equals, signHash)
+}
+
+func (s *RPCSuite) TestError(c *C) {
+	_, err := NewRPCClient(s.server.URL, nil).Fee()
+	c.Assert(err, NotNil)
+	commandError, ok := err.(*CommandError)
+	c.Assert(ok, Equals, true)
+	c.Assert(commandError.Name, Equals, "unknownCmd")
+	c.Assert(commandError.Code, Equals, 32)
+}
+
+func (s *RPCSuite) TestHTTPError(c *C) {
+	s.server.Close()
+	_, err := NewRPCClient(s.server.URL, nil).Fee()
+	c.Assert(err, NotNil)
+	c.Assert(err.(*CommandError).Name, Equals, "Client Error")
+}
+
+func (s *RPCSuite) TestNotConnected(c *C) {
+	for _, client := range []Client{&Remote{}, &RPCClient{}} {
+		_, err := client.Fee()
+		c.Assert(err, ErrorMatches, ".*Not connected, use NewRemote or NewRPCClient.*")
+	}
+}
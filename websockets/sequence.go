@@ -0,0 +1,278 @@
+package websockets
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// SequenceAllocator hands out an account's Sequence numbers and Tickets to
+// concurrent submitters. Sequences which will never be consumed must be
+// Released so the gap they leave is filled by the next allocation, and
+// those which were consumed should be marked as such with Consumed.
+type SequenceAllocator struct {
+	mu          sync.Mutex
+	client      Client
+	account     data.Account
+	loaded      bool
+	next        uint32
+	gaps        []uint32            // released sequences below next, ascending
+	outstanding map[uint32]struct{} // allocated sequences with no outcome yet
+	tickets     []uint32            // available tickets, ascending
+}
+
+func NewSequenceAllocator(client Client, account data.Account) *SequenceAllocator {
+	return &SequenceAllocator{
+		client:      client,
+		account:     account,
+		outstanding: make(map[uint32]struct{}),
+	}
+}
+
+func (a *SequenceAllocator) load() error {
+	info, err := a.client.AccountInfo(a.account)
+	if err != nil {
+		return err
+	}
+	if info.AccountData.Sequence == nil {
+		return fmt.Errorf("sequence: %s has no Sequence", a.account)
+	}
+	sequence := *info.AccountData.Sequence
+	// Sequences already consumed can never fill a gap
+	gaps := a.gaps[:0]
+	for _, gap := range a.gaps {
+		if gap >= sequence {
+			gaps = append(gaps, gap)
+		}
+	}
+	a.gaps = gaps
+	if !a.loaded || sequence > a.next {
+		a.next = sequence
+	}
+	a.loaded = true
+	return nil
+}
+
+// Next returns the lowest released sequence, or the next unused one.
+// The account's Sequence is fetched on first use.
+func (a *SequenceAllocator) Next() (uint32, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.loaded {
+		if err := a.load(); err != nil {
+			return 0, err
+		}
+	}
+	sequence := a.next
+	if len(a.gaps) > 0 {
+		sequence = a.gaps[0]
+		a.gaps = a.gaps[1:]
+	} else {
+		a.next++
+	}
+	a.outstanding[sequence] = struct{}{}
+	return sequence, nil
+}
+
+// Release returns a sequence whose transaction failed without consuming
+// it, ie. tem or tef results or LastLedgerSequence passing. Transactions
+// after it will see terPRE_SEQ until the gap is filled.
+func (a *SequenceAllocator) Release(sequence uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.release(sequence)
+}
+
+func (a *SequenceAllocator) release(sequence uint32) {
+	delete(a.outstanding, sequence)
+	if sequence >= a.next {
+		return
+	}
+	if sequence == a.next-1 {
+		a.next--
+		return
+	}
+	a.gaps = insertSequence(a.gaps, sequence)
+}
+
+// Consumed records that the transaction using sequence was included in a
+// validated ledger
+func (a *SequenceAllocator) Consumed(sequence uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.outstanding, sequence)
+}
+
+// Resync refetches the account's Sequence after another party has used
+// it, ie. tefPAST_SEQ. The next sequence only ever moves forwards, and
+// sequences allocated before then will need reallocating by their callers.
+func (a *SequenceAllocator) Resync() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.load()
+}
+
+// Update applies a preliminary or final engine result for a transaction
+// which used sequence. It returns true if the transaction should be given
+// a new sequence and resubmitted. ter results, including terPRE_SEQ, need
+// no action as the earlier sequences are already allocated.
+func (a *SequenceAllocator) Update(sequence uint32, result data.TransactionResult) (bool, error) {
+	switch {
+	case result.String() == "tefPAST_SEQ":
+		a.Consumed(sequence)
+		return true, a.Resync()
+	case isFinalSubmitResult(result):
+		a.Release(sequence)
+	}
+	return false, nil
+}
+
+// Ticket takes the lowest available ticket, returning false if the pool is empty
+func (a *SequenceAllocator) Ticket() (uint32, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.tickets) == 0 {
+		return 0, false
+	}
+	ticket := a.tickets[0]
+	a.tickets = a.tickets[1:]
+	return ticket, true
+}
+
+// AddTickets puts tickets into the pool, either newly created or returned
+// unused after a transaction failed without consuming its ticket.
+func (a *SequenceAllocator) AddTickets(tickets ...uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, ticket := range tickets {
+		a.tickets = insertSequence(a.tickets, ticket)
+	}
+}
+
+// Tickets returns the number of tickets in the pool
+func (a *SequenceAllocator) Tickets() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.tickets)
+}
+
+// LoadTickets replaces the pool with the account's Ticket ledger entries
+func (a *SequenceAllocator) LoadTickets() error {
+	result, err := a.client.AccountObjects(a.account, "ticket", "validated")
+	if err != nil {
+		return err
+	}
+	var tickets []uint32
+	for _, le := range result.AccountObjects {
+		if ticket, ok := le.(*data.Ticket); ok && ticket.TicketSequence != nil {
+			tickets = append(tickets, *ticket.TicketSequence)
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i] < tickets[j] })
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tickets = tickets
+	return nil
+}
+
+// ReserveTicketCreate allocates the Sequence for a TicketCreate of count
+// tickets. The tickets, which take the following count sequences, are
+// reserved so that Next skips over them.
+func (a *SequenceAllocator) ReserveTicketCreate(count uint32) (*data.TicketCreate, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.loaded {
+		if err := a.load(); err != nil {
+			return nil, err
+		}
+	}
+	sequence := a.next
+	a.next += 1 + count
+	a.outstanding[sequence] = struct{}{}
+	return &data.TicketCreate{
+		TxBase: data.TxBase{
+			TransactionType: data.TICKET_CREATE,
+			Account:         a.account,
+			Sequence:        sequence,
+		},
+		TicketCount: &count,
+	}, nil
+}
+
+// CreateTickets submits a TicketCreate through the submitter and adds the
+// new tickets to the pool once it is validated. If the TicketCreate
+// definitely failed, its reserved sequences are released. If its outcome
+// is unknown they stay allocated, as the tickets may yet be created.
+func (a *SequenceAllocator) CreateTickets(submitter *Submitter, count uint32) error {
+	tx, err := a.ReserveTicketCreate(count)
+	if err != nil {
+		return err
+	}
+	// The tickets take the sequences after the TicketCreate, so it must
+	// not be given another sequence
+	direct := *submitter
+	direct.Sequences = nil
+	outcome, err := direct.Submit(tx)
+	switch {
+	case err == nil && outcome.Result.Success():
+		a.Consumed(tx.Sequence)
+		tickets := make([]uint32, count)
+		for i := range tickets {
+			tickets[i] = tx.Sequence + 1 + uint32(i)
+		}
+		a.AddTickets(tickets...)
+		return nil
+	case outcome == nil:
+		return err
+	case outcome.Validated:
+		// The TicketCreate used its sequence but created no tickets
+		a.releaseTickets(tx.Sequence+1, count)
+		a.Consumed(tx.Sequence)
+		return fmt.Errorf("sequence: TicketCreate %s: %s", outcome.Hash, outcome.Result)
+	case outcome.Expired || outcome.Rejected:
+		a.releaseTickets(tx.Sequence, count+1)
+		if resyncErr := a.resyncIdle(); resyncErr != nil {
+			return resyncErr
+		}
+		return err
+	default:
+		return err
+	}
+}
+
+// Releases count sequences from first, highest first so that a range at
+// the end moves next back rather than leaving gaps
+func (a *SequenceAllocator) releaseTickets(first, count uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := count; i > 0; i-- {
+		a.release(first + i - 1)
+	}
+}
+
+// Resets next to the account's Sequence, even if that moves it backwards,
+// but only when no allocated sequence could still be consumed. Otherwise
+// the released gaps are left for the next allocations.
+func (a *SequenceAllocator) resyncIdle() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.outstanding) > 0 {
+		return nil
+	}
+	a.loaded = false
+	a.gaps = nil
+	return a.load()
+}
+
+func insertSequence(sequences []uint32, sequence uint32) []uint32 {
+	i := sort.Search(len(sequences), func(i int) bool { return sequences[i] >= sequence })
+	if i < len(sequences) && sequences[i] == sequence {
+		return sequences
+	}
+	sequences = append(sequences, 0)
+	copy(sequences[i+1:], sequences[i:])
+	sequences[i] = sequence
+	return sequences
+}
@@ -0,0 +1,145 @@
+package websockets
+
+import (
+	"net/http/httptest"
+	"sort"
+	"sync"
+
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func newTestAllocator(c *C, f *fakeRippled) (*SequenceAllocator, func()) {
+	server := httptest.NewServer(f)
+	account, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	return NewSequenceAllocator(NewRPCClient(server.URL, nil), *account), server.Close
+}
+
+func (s *SubmitterSuite) TestConcurrentSequences(c *C) {
+	allocator, done := newTestAllocator(c, &fakeRippled{sequence: 7})
+	defer done()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		sequences []uint32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sequence, err := allocator.Next()
+			c.Check(err, IsNil)
+			mu.Lock()
+			sequences = append(sequences, sequence)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Slice(sequences, func(i, j int) bool { return sequences[i] < sequences[j] })
+	c.Assert(sequences, HasLen, 50)
+	for i, sequence := range sequences {
+		c.Assert(sequence, Equals, uint32(7+i))
+	}
+}
+
+func (s *SubmitterSuite) TestSequenceGaps(c *C) {
+	f := &fakeRippled{sequence: 7}
+	allocator, done := newTestAllocator(c, f)
+	defer done()
+
+	for i := uint32(7); i < 11; i++ {
+		sequence, err := allocator.Next()
+		c.Assert(err, IsNil)
+		c.Assert(sequence, Equals, i)
+	}
+	// A malformed transaction leaves a gap which is filled first
+	reallocate, err := allocator.Update(8, mustResult(c, "temBAD_FEE"))
+	c.Assert(err, IsNil)
+	c.Assert(reallocate, Equals, false)
+	allocator.Release(10)
+	next, err := allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(next, Equals, uint32(8))
+	next, err = allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(next, Equals, uint32(10))
+
+	// Waiting on an earlier sequence needs no action
+	reallocate, err = allocator.Update(10, mustResult(c, "terPRE_SEQ"))
+	c.Assert(err, IsNil)
+	c.Assert(reallocate, Equals, false)
+
+	// Someone else used the account
+	f.sequence = 20
+	reallocate, err = allocator.Update(10, mustResult(c, "tefPAST_SEQ"))
+	c.Assert(err, IsNil)
+	c.Assert(reallocate, Equals, true)
+	next, err = allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(next, Equals, uint32(20))
+}
+
+func (s *SubmitterSuite) TestTickets(c *C) {
+	f := &fakeRippled{sequence: 7, tickets: []uint32{5, 3, 4}}
+	allocator, done := newTestAllocator(c, f)
+	defer done()
+
+	c.Assert(allocator.LoadTickets(), IsNil)
+	c.Assert(allocator.Tickets(), Equals, 3)
+	ticket, ok := allocator.Ticket()
+	c.Assert(ok, Equals, true)
+	c.Assert(ticket, Equals, uint32(3))
+	allocator.AddTickets(3, 12)
+	for _, expected := range []uint32{3, 4, 5, 12} {
+		ticket, ok = allocator.Ticket()
+		c.Assert(ok, Equals, true)
+		c.Assert(ticket, Equals, expected)
+	}
+	_, ok = allocator.Ticket()
+	c.Assert(ok, Equals, false)
+
+	// The created tickets take the sequences after the TicketCreate
+	tx, err := allocator.ReserveTicketCreate(5)
+	c.Assert(err, IsNil)
+	c.Assert(tx.Sequence, Equals, uint32(7))
+	c.Assert(*tx.TicketCount, Equals, uint32(5))
+	next, err := allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(next, Equals, uint32(13))
+}
+
+func (s *SubmitterSuite) TestCreateTickets(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, results: []string{"tesSUCCESS"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+	allocator := newTestAllocatorFor(c, submitter)
+
+	c.Assert(allocator.CreateTickets(submitter, 3), IsNil)
+	c.Assert(f.submissions[0].GetBase().Sequence, Equals, uint32(7))
+	for _, expected := range []uint32{8, 9, 10} {
+		ticket, ok := allocator.Ticket()
+		c.Assert(ok, Equals, true)
+		c.Assert(ticket, Equals, expected)
+	}
+
+	// A sequence still in flight stops the failed TicketCreate from
+	// moving next back to the account's Sequence
+	sequence, err := allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(sequence, Equals, uint32(11))
+	f.results = []string{"temBAD_FEE"}
+	c.Assert(allocator.CreateTickets(submitter, 3), ErrorMatches, ".*temBAD_FEE")
+	c.Assert(f.submissions[1].GetBase().Sequence, Equals, uint32(12))
+	c.Assert(allocator.Tickets(), Equals, 0)
+	sequence, err = allocator.Next()
+	c.Assert(err, IsNil)
+	c.Assert(sequence, Equals, uint32(12))
+}
+
+func mustResult(c *C, name string) data.TransactionResult {
+	var result data.TransactionResult
+	c.Assert(result.UnmarshalText([]byte(name)), IsNil)
+	return result
+}
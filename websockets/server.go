@@ -0,0 +1,331 @@
+package websockets
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// https://xrpl.org/server_info.html
+type ServerInfoCommand struct {
+	*Command
+	Result *ServerInfoResult `json:"result,omitempty"`
+}
+
+type ServerInfoResult struct {
+	Info ServerInfo `json:"info"`
+}
+
+type LastClose struct {
+	ConvergeTime float64 `json:"converge_time_s"`
+	Proposers    uint32  `json:"proposers"`
+}
+
+type ServerInfoLedger struct {
+	Age              uint32       `json:"age"`
+	BaseFeeXRP       float64      `json:"base_fee_xrp"`
+	Hash             data.Hash256 `json:"hash"`
+	ReserveBaseXRP   float64      `json:"reserve_base_xrp"`
+	ReserveIncXRP    float64      `json:"reserve_inc_xrp"`
+	LedgerSequence   uint32       `json:"seq"`
+	CloseTime        uint32       `json:"close_time,omitempty"`
+	BaseFee          uint64       `json:"base_fee,omitempty"`
+	ReserveBase      uint64       `json:"reserve_base,omitempty"`
+	ReserveIncrement uint64       `json:"reserve_inc,omitempty"`
+}
+
+// Fields common to server_info and server_state
+type serverStatus struct {
+	AmendmentBlocked     bool              `json:"amendment_blocked,omitempty"`
+	BuildVersion         string            `json:"build_version"`
+	CompleteLedgers      string            `json:"complete_ledgers"`
+	HostID               string            `json:"hostid"`
+	IOLatency            uint32            `json:"io_latency_ms"`
+	JobQueueOverflow     string            `json:"jq_trans_overflow"`
+	LastClose            LastClose         `json:"last_close"`
+	NetworkID            *uint32           `json:"network_id,omitempty"`
+	PeerDisconnects      string            `json:"peer_disconnects"`
+	Peers                uint32            `json:"peers"`
+	NodePublicKey        string            `json:"pubkey_node"`
+	ValidatorPublicKey   string            `json:"pubkey_validator"`
+	ServerState          string            `json:"server_state"`
+	ServerStateDuration  string            `json:"server_state_duration_us"`
+	Time                 string            `json:"time"`
+	Uptime               uint64            `json:"uptime"`
+	ValidatedLedger      *ServerInfoLedger `json:"validated_ledger,omitempty"`
+	ClosedLedger         *ServerInfoLedger `json:"closed_ledger,omitempty"`
+	ValidationQuorum     uint32            `json:"validation_quorum"`
+	ValidatorListExpires string            `json:"validator_list_expires,omitempty"`
+}
+
+// Ledger ranges available on the server
+func (s *serverStatus) Ledgers() (data.LedgerRangeSlice, error) {
+	return data.NewLedgerRangeSlice(s.CompleteLedgers)
+}
+
+type ServerInfo struct {
+	serverStatus
+	LoadFactor float64 `json:"load_factor"`
+}
+
+// https://xrpl.org/server_state.html
+type ServerStateCommand struct {
+	*Command
+	Result *ServerStateResult `json:"result,omitempty"`
+}
+
+type ServerStateResult struct {
+	State ServerState `json:"state"`
+}
+
+type ServerState struct {
+	serverStatus
+	LoadBase                uint64 `json:"load_base"`
+	LoadFactor              uint64 `json:"load_factor"`
+	LoadFactorFeeEscalation uint64 `json:"load_factor_fee_escalation"`
+	LoadFactorFeeQueue      uint64 `json:"load_factor_fee_queue"`
+	LoadFactorFeeReference  uint64 `json:"load_factor_fee_reference"`
+	LoadFactorServer        uint64 `json:"load_factor_server"`
+}
+
+type LedgerClosedCommand struct {
+	*Command
+	Result *LedgerClosedResult `json:"result,omitempty"`
+}
+
+type LedgerClosedResult struct {
+	LedgerHash     data.Hash256 `json:"ledger_hash"`
+	LedgerSequence uint32       `json:"ledger_index"`
+}
+
+type LedgerCurrentCommand struct {
+	*Command
+	Result *LedgerCurrentResult `json:"result,omitempty"`
+}
+
+type LedgerCurrentResult struct {
+	LedgerSequence uint32 `json:"ledger_current_index"`
+}
+
+// https://xrpl.org/manifest.html
+type ManifestCommand struct {
+	*Command
+	PublicKey string          `json:"public_key"`
+	Result    *ManifestResult `json:"result,omitempty"`
+}
+
+type ManifestDetails struct {
+	Domain       string `json:"domain"`
+	EphemeralKey string `json:"ephemeral_key"`
+	MasterKey    string `json:"master_key"`
+	Sequence     uint32 `json:"seq"`
+}
+
+type ManifestResult struct {
+	Details   *ManifestDetails `json:"details,omitempty"`
+	Manifest  string           `json:"manifest,omitempty"`
+	Requested string           `json:"requested"`
+}
+
+// https://xrpl.org/feature.html
+type FeatureCommand struct {
+	*Command
+	Feature string         `json:"feature,omitempty"`
+	Result  *FeatureResult `json:"result,omitempty"`
+}
+
+type Feature struct {
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	Supported bool   `json:"supported"`
+	// Either a bool or "Obsolete"
+	Vetoed      interface{} `json:"vetoed,omitempty"`
+	Count       *uint32     `json:"count,omitempty"`
+	Threshold   *uint32     `json:"threshold,omitempty"`
+	Validations *uint32     `json:"validations,omitempty"`
+	Majority    *uint32     `json:"majority,omitempty"`
+}
+
+type FeatureResult struct {
+	Features map[data.Hash256]Feature `json:"features"`
+}
+
+// A single requested feature is returned keyed by its id without the "features" wrapper
+func (f *FeatureResult) UnmarshalJSON(b []byte) error {
+	var extract map[string]json.RawMessage
+	if err := json.Unmarshal(b, &extract); err != nil {
+		return err
+	}
+	f.Features = make(map[data.Hash256]Feature)
+	if features, ok := extract["features"]; ok {
+		return json.Unmarshal(features, &f.Features)
+	}
+	for key, value := range extract {
+		id, err := data.NewHash256(key)
+		if err != nil {
+			continue
+		}
+		var feature Feature
+		if err := json.Unmarshal(value, &feature); err != nil {
+			return err
+		}
+		f.Features[*id] = feature
+	}
+	return nil
+}
+
+// An amendment from the Amendments ledger entry with its name taken from feature
+type AmendmentStatus struct {
+	Amendment data.Hash256
+	Name      string
+	Enabled   bool
+	Majority  *data.RippleTime
+}
+
+func (a AmendmentStatus) String() string {
+	switch {
+	case a.Enabled:
+		return fmt.Sprintf("%s %s enabled", a.Amendment, a.Name)
+	case a.Majority != nil:
+		return fmt.Sprintf("%s %s majority since %s", a.Amendment, a.Name, a.Majority.String())
+	default:
+		return fmt.Sprintf("%s %s", a.Amendment, a.Name)
+	}
+}
+
+func (r *requester) ServerInfo() (*ServerInfoResult, error) {
+	cmd := &ServerInfoCommand{
+		Command: newCommand("server_info"),
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+func (r *requester) ServerState() (*ServerStateResult, error) {
+	cmd := &ServerStateCommand{
+		Command: newCommand("server_state"),
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+func (r *requester) LedgerClosed() (*LedgerClosedResult, error) {
+	cmd := &LedgerClosedCommand{
+		Command: newCommand("ledger_closed"),
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+func (r *requester) LedgerCurrent() (*LedgerCurrentResult, error) {
+	cmd := &LedgerCurrentCommand{
+		Command: newCommand("ledger_current"),
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+// Requests the latest manifest for a validator's base58 master or ephemeral public key
+func (r *requester) Manifest(publicKey string) (*ManifestResult, error) {
+	cmd := &ManifestCommand{
+		Command:   newCommand("manifest"),
+		PublicKey: publicKey,
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+// Requests the status of all amendments known to the server, or a single one
+// if feature is a name or hex id.
+func (r *requester) Feature(feature string) (*FeatureResult, error) {
+	cmd := &FeatureCommand{
+		Command: newCommand("feature"),
+		Feature: feature,
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+// Amendments returns the enabled amendments and those with a majority from
+// the Amendments ledger entry, named using the server's feature list.
+func (r *requester) Amendments(ledger interface{}) ([]AmendmentStatus, error) {
+	index, err := data.GetAmendmentsIndex()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := r.LedgerEntry(*index, ledger)
+	if err != nil {
+		return nil, err
+	}
+	amendments, ok := entry.LedgerEntry.(*data.Amendments)
+	if !ok {
+		return nil, fmt.Errorf("ledger_entry: unexpected %s", entry.LedgerEntry.GetType())
+	}
+	features, err := r.Feature("")
+	if err != nil {
+		return nil, err
+	}
+	return resolveAmendments(amendments, features.Features), nil
+}
+
+func resolveAmendments(amendments *data.Amendments, features map[data.Hash256]Feature) []AmendmentStatus {
+	var statuses []AmendmentStatus
+	name := func(amendment data.Hash256) string {
+		if feature, ok := features[amendment]; ok && feature.Name != "" {
+			return feature.Name
+		}
+		return "unknown"
+	}
+	if amendments.Amendments != nil {
+		for _, amendment := range *amendments.Amendments {
+			statuses = append(statuses, AmendmentStatus{
+				Amendment: amendment,
+				Name:      name(amendment),
+				Enabled:   true,
+			})
+		}
+	}
+	for _, majority := range amendments.Majorities {
+		if majority.Amendment == nil {
+			continue
+		}
+		status := AmendmentStatus{
+			Amendment: *majority.Amendment,
+			Name:      name(*majority.Amendment),
+		}
+		if majority.CloseTime != nil {
+			status.Majority = data.NewRippleTime(*majority.CloseTime)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
@@ -0,0 +1,129 @@
+package websockets
+
+import (
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+func (s *MessagesSuite) TestServerInfoResponse(c *C) {
+	msg := &ServerInfoCommand{}
+	readResponseFile(c, msg, "testdata/server_info.json")
+
+	c.Assert(msg.Status, Equals, "success")
+	c.Assert(msg.Type, Equals, "response")
+
+	info := msg.Result.Info
+	c.Assert(info.ServerState, Equals, "full")
+	c.Assert(info.LastClose.Proposers, Equals, uint32(34))
+	c.Assert(info.ValidatedLedger.LedgerSequence, Equals, uint32(6959300))
+	c.Assert(info.ValidatedLedger.BaseFeeXRP, Equals, 0.00001)
+	c.Assert(info.ValidationQuorum, Equals, uint32(28))
+	ledgers, err := info.Ledgers()
+	c.Assert(err, IsNil)
+	c.Assert(ledgers, HasLen, 2)
+	c.Assert(ledgers.Contains(6959229), Equals, false)
+	c.Assert(ledgers.Contains(6959300), Equals, true)
+}
+
+func (s *MessagesSuite) TestServerStateResponse(c *C) {
+	msg := &ServerStateCommand{}
+	readResponseFile(c, msg, "testdata/server_state.json")
+
+	state := msg.Result.State
+	c.Assert(state.ServerState, Equals, "syncing")
+	c.Assert(state.LoadBase, Equals, uint64(256))
+	ledgers, err := state.Ledgers()
+	c.Assert(err, IsNil)
+	c.Assert(ledgers, HasLen, 0)
+}
+
+func (s *MessagesSuite) TestFeatureResponse(c *C) {
+	amm, err := data.NewHash256("8CC0774A3BF66D1D22E76BBDA8E8A232E6B6313834301B3B23E8601196AE6455")
+	c.Assert(err, IsNil)
+	escalation, err := data.NewHash256("42426C4D4F1009EE67080A9B7965B44656D7714D104A72F9B4369F97ABF044EE")
+	c.Assert(err, IsNil)
+
+	msg := &FeatureCommand{}
+	readResponseFile(c, msg, "testdata/feature.json")
+	c.Assert(msg.Result.Features, HasLen, 2)
+	c.Assert(msg.Result.Features[*amm].Name, Equals, "AMM")
+	c.Assert(*msg.Result.Features[*amm].Count, Equals, uint32(25))
+	c.Assert(msg.Result.Features[*escalation].Vetoed, Equals, "Obsolete")
+
+	single := &FeatureCommand{}
+	readResponseFile(c, single, "testdata/feature_single.json")
+	c.Assert(single.Result.Features, HasLen, 1)
+	c.Assert(single.Result.Features[*amm].Supported, Equals, true)
+
+	closeTime := uint32(741000000)
+	amendments := &data.Amendments{
+		Amendments: &data.Vector256{*escalation},
+		Majorities: []data.Majority{{Amendment: amm, CloseTime: &closeTime}},
+	}
+	statuses := resolveAmendments(amendments, msg.Result.Features)
+	c.Assert(statuses, HasLen, 2)
+	c.Assert(statuses[0].Name, Equals, "AMM")
+	c.Assert(statuses[0].Enabled, Equals, false)
+	c.Assert(statuses[0].Majority.T, Equals, closeTime)
+	c.Assert(statuses[1].Name, Equals, "FeeEscalation")
+	c.Assert(statuses[1].Enabled, Equals, true)
+}
+
+// Returns a Remote which answers subscribe and unsubscribe commands itself
+func newLedgerRemote() (*Remote, chan Syncer, func()) {
+	r := &Remote{outgoing: make(chan Syncer)}
+	r.requester.sender = r.enqueue
+	commands := make(chan Syncer, 10)
+	go func() {
+		for cmd := range r.outgoing {
+			if subscribe, ok := cmd.(*SubscribeCommand); ok {
+				subscribe.Result = &SubscribeResult{LedgerStreamMsg: &LedgerStreamMsg{LedgerSequence: 100}}
+			}
+			commands <- cmd
+			cmd.Done()
+		}
+	}()
+	return r, commands, func() { close(r.outgoing) }
+}
+
+func (s *MessagesSuite) TestWaitForLedger(c *C) {
+	r, commands, done := newLedgerRemote()
+	defer done()
+
+	ledger, err := r.WaitForLedger(100)
+	c.Assert(err, IsNil)
+	c.Assert(ledger.LedgerSequence, Equals, uint32(100))
+
+	results := make(chan *LedgerStreamMsg, 2)
+	for _, sequence := range []uint32{101, 102} {
+		go func(sequence uint32) {
+			ledger, err := r.WaitForLedger(sequence)
+			c.Check(err, IsNil)
+			results <- ledger
+		}(sequence)
+	}
+	r.notifyWaiters(&LedgerStreamMsg{LedgerSequence: 101})
+	c.Assert((<-results).LedgerSequence, Equals, uint32(101))
+	r.notifyWaiters(&LedgerStreamMsg{LedgerSequence: 102})
+	c.Assert((<-results).LedgerSequence, Equals, uint32(102))
+	c.Assert(commands, HasLen, 1)
+}
+
+func (s *MessagesSuite) TestLedgerStreamDelivery(c *C) {
+	r, commands, done := newLedgerRemote()
+	defer done()
+
+	// Only the waiters use the ledger stream
+	_, err := r.WaitForLedger(100)
+	c.Assert(err, IsNil)
+	c.Assert(r.notifyWaiters(&LedgerStreamMsg{LedgerSequence: 101}), Equals, false)
+
+	_, err = r.Subscribe(true, false, false, false)
+	c.Assert(err, IsNil)
+	c.Assert(r.notifyWaiters(&LedgerStreamMsg{LedgerSequence: 102}), Equals, true)
+
+	// The waiters keep the ledger stream subscribed
+	c.Assert(r.Unsubscribe(SubscribeRequest{Streams: []string{StreamLedger}}), IsNil)
+	c.Assert(r.notifyWaiters(&LedgerStreamMsg{LedgerSequence: 103}), Equals, false)
+	c.Assert(commands, HasLen, 2)
+}
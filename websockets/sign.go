@@ -0,0 +1,248 @@
+package websockets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// https://xrpl.org/sign.html
+type SignCommand struct {
+	*Command
+	TxJson  map[string]interface{} `json:"tx_json"`
+	Seed    data.Seed              `json:"seed"`
+	KeyType string                 `json:"key_type"`
+	Offline bool                   `json:"offline"`
+	Result  *SignResult            `json:"result,omitempty"`
+}
+
+// https://xrpl.org/sign_for.html
+type SignForCommand struct {
+	*Command
+	Account data.Account           `json:"account"`
+	TxJson  map[string]interface{} `json:"tx_json"`
+	Seed    data.Seed              `json:"seed"`
+	KeyType string                 `json:"key_type"`
+	Result  *SignResult            `json:"result,omitempty"`
+}
+
+type SignResult struct {
+	TxBlob      string           `json:"tx_blob"`
+	Tx          interface{}      `json:"tx_json"`
+	Transaction data.Transaction `json:"-"`
+}
+
+// https://xrpl.org/submit_multisigned.html
+type SubmitMultisignedCommand struct {
+	*Command
+	TxJson map[string]interface{} `json:"tx_json"`
+	Result *SubmitResult          `json:"result,omitempty"`
+}
+
+// https://xrpl.org/simulate.html
+type SimulateCommand struct {
+	*Command
+	TxBlob string          `json:"tx_blob"`
+	Binary bool            `json:"binary"`
+	Result *SimulateResult `json:"result,omitempty"`
+}
+
+type SimulateResult struct {
+	Applied                 bool                         `json:"applied"`
+	EngineResult            data.TransactionResult       `json:"engine_result"`
+	EngineResultCode        int                          `json:"engine_result_code"`
+	EngineResultMessage     string                       `json:"engine_result_message"`
+	LedgerSequence          uint32                       `json:"ledger_index"`
+	TxBlob                  string                       `json:"tx_blob"`
+	MetaBlob                string                       `json:"meta_blob"`
+	TransactionWithMetaData data.TransactionWithMetaData `json:"-"`
+}
+
+// rippled names key types differently to data.KeyType's String()
+func keyTypeName(keyType data.KeyType) string {
+	if keyType == data.Ed25519 {
+		return "ed25519"
+	}
+	return "secp256k1"
+}
+
+// Converts a transaction into tx_json, leaving out the hash and any
+// Sequence or Fee which are zero so that rippled can autofill them.
+func txJSON(tx data.Transaction) (map[string]interface{}, error) {
+	b, err := json.Marshal(tx)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "hash")
+	base := tx.GetBase()
+	if base.Sequence == 0 {
+		delete(m, "Sequence")
+	}
+	if base.Fee.IsZero() {
+		delete(m, "Fee")
+	}
+	return m, nil
+}
+
+// Decodes a hex transaction blob and sets its hash
+func readTxBlob(blob string) (data.Transaction, error) {
+	b, err := hex.DecodeString(blob)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := data.ReadTransaction(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	hash, _, err := data.Raw(tx)
+	if err != nil {
+		return nil, err
+	}
+	*tx.GetHash() = hash
+	return tx, nil
+}
+
+// Decodes the binary transaction and metadata of a simulate response
+func readSimulateResult(result *SimulateResult) (*data.TransactionWithMetaData, error) {
+	tx, err := readTxBlob(result.TxBlob)
+	if err != nil {
+		return nil, err
+	}
+	txb, err := hex.DecodeString(result.TxBlob)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := hex.DecodeString(result.MetaBlob)
+	if err != nil {
+		return nil, err
+	}
+	return data.ReadTransactionAndMetadata(bytes.NewReader(txb), bytes.NewReader(meta), *tx.GetHash(), result.LedgerSequence)
+}
+
+// Asks rippled to sign a transaction with the supplied seed. Sequence and
+// Fee are autofilled by the server when zero unless offline is set.
+func (r *requester) Sign(tx data.Transaction, seed data.Seed, keyType data.KeyType, offline bool) (*SignResult, error) {
+	txJson, err := txJSON(tx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &SignCommand{
+		Command: newCommand("sign"),
+		TxJson:  txJson,
+		Seed:    seed,
+		KeyType: keyTypeName(keyType),
+		Offline: offline,
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	if cmd.Result.Transaction, err = readTxBlob(cmd.Result.TxBlob); err != nil {
+		return nil, err
+	}
+	return cmd.Result, nil
+}
+
+// Asks rippled to add account's signature to a multi-signed transaction.
+// The returned transaction includes any Signers already present.
+func (r *requester) SignFor(tx data.Transaction, account data.Account, seed data.Seed, keyType data.KeyType) (*SignResult, error) {
+	txJson, err := txJSON(tx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &SignForCommand{
+		Command: newCommand("sign_for"),
+		Account: account,
+		TxJson:  txJson,
+		Seed:    seed,
+		KeyType: keyTypeName(keyType),
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	if cmd.Result.Transaction, err = readTxBlob(cmd.Result.TxBlob); err != nil {
+		return nil, err
+	}
+	return cmd.Result, nil
+}
+
+func (r *requester) SubmitMultisigned(tx data.Transaction) (*SubmitResult, error) {
+	if len(tx.GetBase().Signers) == 0 {
+		return nil, fmt.Errorf("submit_multisigned: transaction has no Signers")
+	}
+	txJson, err := txJSON(tx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &SubmitMultisignedCommand{
+		Command: newCommand("submit_multisigned"),
+		TxJson:  txJson,
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	return cmd.Result, nil
+}
+
+// Returns a copy of tx with its signatures removed and an empty
+// SigningPubKey, which is the only form simulate accepts
+func unsignedCopy(tx data.Transaction) (data.Transaction, error) {
+	_, raw, err := data.Raw(tx)
+	if err != nil {
+		return nil, err
+	}
+	unsigned, err := data.ReadTransaction(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	base := unsigned.GetBase()
+	base.TxnSignature = nil
+	base.SigningPubKey = new(data.PublicKey)
+	for i := range base.Signers {
+		base.Signers[i].Signer.TxnSignature = nil
+	}
+	return unsigned, nil
+}
+
+// Runs a transaction against the current open ledger without applying it.
+// The result includes the metadata it would have produced. Any signatures
+// are removed from a copy of the transaction first, as simulate refuses
+// signed transactions.
+func (r *requester) Simulate(tx data.Transaction) (*SimulateResult, error) {
+	unsigned, err := unsignedCopy(tx)
+	if err != nil {
+		return nil, err
+	}
+	_, raw, err := data.Raw(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	cmd := &SimulateCommand{
+		Command: newCommand("simulate"),
+		TxBlob:  fmt.Sprintf("%X", raw),
+		Binary:  true,
+	}
+	r.send(cmd)
+	<-cmd.Ready
+	if cmd.CommandError != nil {
+		return nil, cmd.CommandError
+	}
+	txm, err := readSimulateResult(cmd.Result)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Result.TransactionWithMetaData = *txm
+	return cmd.Result, nil
+}
@@ -0,0 +1,77 @@
+package websockets
+
+import (
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+const (
+	signHash = "2D0CE11154B655A2BFE7F3F857AAC344622EC7DAB11B1EBD920DCDB00E8646FF"
+	// The same transaction unsigned, as simulate requires
+	simulateHash = "FD9C656B3AE3D796CA5447F0927F6978AB1E9EF5D53CBF8DB0898AD86D6A79EA"
+)
+
+func (s *MessagesSuite) TestSignResponse(c *C) {
+	msg := &SignCommand{}
+	readResponseFile(c, msg, "testdata/sign.json")
+
+	// Response fields
+	c.Assert(msg.Status, Equals, "success")
+	c.Assert(msg.Type, Equals, "response")
+
+	tx, err := readTxBlob(msg.Result.TxBlob)
+	c.Assert(err, IsNil)
+	c.Assert(tx.GetTransactionType(), Equals, data.OFFER_CREATE)
+	c.Assert(tx.GetBase().Account.String(), Equals, "rwpxNWdpKu2QVgrh5LQXEygYLshhgnRL1Y")
+	c.Assert(tx.GetBase().Sequence, Equals, uint32(1681497))
+	c.Assert(tx.GetHash().String(), Equals, signHash)
+	ok, err := data.CheckSignature(tx)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *MessagesSuite) TestSimulateResponse(c *C) {
+	msg := &SimulateCommand{}
+	readResponseFile(c, msg, "testdata/simulate.json")
+
+	// Response fields
+	c.Assert(msg.Status, Equals, "success")
+	c.Assert(msg.Type, Equals, "response")
+
+	// Result fields
+	c.Assert(msg.Result.Applied, Equals, false)
+	c.Assert(msg.Result.EngineResult.String(), Equals, "tesSUCCESS")
+	c.Assert(msg.Result.LedgerSequence, Equals, uint32(6917762))
+
+	txm, err := readSimulateResult(msg.Result)
+	c.Assert(err, IsNil)
+	c.Assert(txm.GetTransactionType(), Equals, data.OFFER_CREATE)
+	c.Assert(txm.GetHash().String(), Equals, simulateHash)
+	c.Assert(txm.GetBase().TxnSignature, IsNil)
+	c.Assert(txm.GetBase().SigningPubKey.IsZero(), Equals, true)
+	c.Assert(txm.LedgerSequence, Equals, uint32(6917762))
+	c.Assert(txm.MetaData.TransactionResult.String(), Equals, "tesSUCCESS")
+	c.Assert(txm.MetaData.AffectedNodes, HasLen, 4)
+}
+
+func (s *MessagesSuite) TestTxJSON(c *C) {
+	account, err := data.NewAccountFromAddress("rwpxNWdpKu2QVgrh5LQXEygYLshhgnRL1Y")
+	c.Assert(err, IsNil)
+	tx := &data.AccountSet{TxBase: data.TxBase{
+		TransactionType: data.ACCOUNT_SET,
+		Account:         *account,
+	}}
+	fields, err := txJSON(tx)
+	c.Assert(err, IsNil)
+	c.Assert(fields["TransactionType"], Equals, "AccountSet")
+	c.Assert(fields["Account"], Equals, "rwpxNWdpKu2QVgrh5LQXEygYLshhgnRL1Y")
+	for _, name := range []string{"hash", "Sequence", "Fee"} {
+		_, ok := fields[name]
+		c.Assert(ok, Equals, false, Commentf(name))
+	}
+
+	tx.Sequence = 5
+	fields, err = txJSON(tx)
+	c.Assert(err, IsNil)
+	c.Assert(fields["Sequence"], Equals, 5.0)
+}
@@ -0,0 +1,314 @@
+package websockets
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Submitter autofills, signs and submits transactions and then follows
+// them until they are validated or their LastLedgerSequence has passed.
+type Submitter struct {
+	client Client
+	signer crypto.Signer
+
+	// Ledgers after the current open ledger to use as LastLedgerSequence
+	// when the transaction does not set one
+	LedgerOffset uint32
+	// Upper bound on an autofilled Fee, nil for no limit
+	MaxFee *data.Value
+	// How often to poll when the client cannot stream ledgers
+	PollInterval time.Duration
+	// Allocates sequences instead of AccountInfo when set, so that
+	// transactions can be submitted concurrently
+	Sequences *SequenceAllocator
+	// Takes a ticket from Sequences in place of a sequence when one is
+	// available, so that transactions do not wait on each other
+	UseTickets bool
+}
+
+// The final outcome of a submitted transaction
+type SubmitOutcome struct {
+	Hash data.Hash256
+	// From the validated metadata, or the last preliminary result if the
+	// transaction expired
+	Result data.TransactionResult
+	// The transaction was included in a validated ledger. Its Result may
+	// still be a tec failure.
+	Validated bool
+	// LastLedgerSequence passed without the transaction being validated
+	Expired bool
+	// A tef or tem result means the transaction can never be validated
+	Rejected    bool
+	Submissions int
+	Transaction *data.TransactionWithMetaData
+}
+
+func NewSubmitter(client Client, key crypto.Key, keySequence *uint32) *Submitter {
+	return NewSignerSubmitter(client, crypto.NewKeySigner(key, keySequence))
+}
+
+// NewSignerSubmitter signs with a Signer, ie. one whose key is held by a
+// separate signing process
+func NewSignerSubmitter(client Client, signer crypto.Signer) *Submitter {
+	return &Submitter{
+		client:       client,
+		signer:       signer,
+		LedgerOffset: 20,
+		PollInterval: 4 * time.Second,
+	}
+}
+
+// Fills in Account, Sequence, Fee and LastLedgerSequence if they are unset
+func (s *Submitter) Autofill(tx data.Transaction) error {
+	base := tx.GetBase()
+	if base.Account.IsZero() {
+		id, err := crypto.SignerId(s.signer)
+		if err != nil {
+			return err
+		}
+		copy(base.Account[:], id)
+	}
+	switch {
+	case base.Sequence != 0 || data.TicketSequence(tx) != nil:
+	case s.Sequences != nil && s.UseTickets && s.takeTicket(tx):
+	case s.Sequences != nil:
+		sequence, err := s.Sequences.Next()
+		if err != nil {
+			return err
+		}
+		base.Sequence = sequence
+	default:
+		info, err := s.client.AccountInfo(base.Account)
+		if err != nil {
+			return err
+		}
+		base.Sequence = *info.AccountData.Sequence
+	}
+	if base.Fee.IsZero() {
+		fee, err := s.client.Fee()
+		if err != nil {
+			return err
+		}
+		base.Fee = fee.Suggested()
+		if s.MaxFee != nil && s.MaxFee.Less(base.Fee) {
+			base.Fee = *s.MaxFee
+		}
+	}
+	if base.LastLedgerSequence == nil {
+		current, err := s.client.LedgerCurrent()
+		if err != nil {
+			return err
+		}
+		lastLedger := current.LedgerSequence + s.LedgerOffset
+		base.LastLedgerSequence = &lastLedger
+	}
+	return nil
+}
+
+// Uses a ticket from the pool if there is one and the transaction can use it
+func (s *Submitter) takeTicket(tx data.Transaction) bool {
+	ticket, ok := s.Sequences.Ticket()
+	if !ok {
+		return false
+	}
+	if !data.SetTicketSequence(tx, ticket) {
+		s.Sequences.AddTickets(ticket)
+		return false
+	}
+	return true
+}
+
+// Autofills, signs and submits the transaction, then blocks until the
+// outcome is final. ter and tel results are resubmitted each ledger until
+// LastLedgerSequence. An error is returned with the outcome if the
+// transaction expired.
+func (s *Submitter) Submit(tx data.Transaction) (*SubmitOutcome, error) {
+	var (
+		outcome *SubmitOutcome
+		result  *SubmitResult
+		start   uint32
+	)
+	for {
+		if err := s.Autofill(tx); err != nil {
+			return nil, err
+		}
+		if err := data.SignWith(tx, s.signer); err != nil {
+			return nil, err
+		}
+		outcome = &SubmitOutcome{
+			Hash: *tx.GetHash(),
+		}
+		var err error
+		if start, err = s.validatedLedger(); err != nil {
+			return nil, err
+		}
+		if result, err = s.submit(tx, outcome); err != nil {
+			return nil, err
+		}
+		if !isFinalSubmitResult(result.EngineResult) {
+			break
+		}
+		outcome.Rejected = true
+		reallocate, err := s.rejected(tx, result.EngineResult)
+		if err != nil {
+			return outcome, err
+		}
+		if !reallocate {
+			return outcome, fmt.Errorf("submit: %s %s", outcome.Hash, result.EngineResult)
+		}
+		tx.GetBase().Sequence = 0
+	}
+	lastLedger := *tx.GetBase().LastLedgerSequence
+	retry := isRetrySubmitResult(result.EngineResult)
+	for {
+		validated, err := s.wait()
+		if err != nil {
+			return outcome, err
+		}
+		done, err := s.check(outcome)
+		if err != nil {
+			return outcome, err
+		}
+		if done {
+			s.consumed(tx)
+			return outcome, nil
+		}
+		if validated >= lastLedger {
+			if err := s.expired(outcome, start, lastLedger); err != nil {
+				return outcome, err
+			}
+			s.unused(tx)
+			return outcome, fmt.Errorf("submit: %s expired at ledger %d: %s", outcome.Hash, lastLedger, outcome.Result)
+		}
+		if retry {
+			result, err := s.submit(tx, outcome)
+			if err != nil {
+				return outcome, err
+			}
+			// Past sequence or already seen means an earlier submission got in
+			retry = isRetrySubmitResult(result.EngineResult)
+		}
+	}
+}
+
+// Handles a tef or tem result, returning true if the transaction should be
+// given a new sequence and resubmitted
+func (s *Submitter) rejected(tx data.Transaction, result data.TransactionResult) (bool, error) {
+	switch {
+	case s.Sequences == nil:
+	case data.TicketSequence(tx) == nil:
+		return s.Sequences.Update(tx.GetBase().Sequence, result)
+	case result.String() != "tefNO_TICKET":
+		s.unused(tx)
+	}
+	return false, nil
+}
+
+// Tells the allocator that the transaction's sequence was used
+func (s *Submitter) consumed(tx data.Transaction) {
+	if s.Sequences != nil && data.TicketSequence(tx) == nil {
+		s.Sequences.Consumed(tx.GetBase().Sequence)
+	}
+}
+
+// Returns the sequence, or a ticket from the pool, of a transaction which
+// will never be validated
+func (s *Submitter) unused(tx data.Transaction) {
+	switch ticket := data.TicketSequence(tx); {
+	case s.Sequences == nil:
+	case ticket == nil:
+		s.Sequences.Release(tx.GetBase().Sequence)
+	case s.UseTickets:
+		s.Sequences.AddTickets(*ticket)
+	}
+}
+
+func (s *Submitter) submit(tx data.Transaction, outcome *SubmitOutcome) (*SubmitResult, error) {
+	result, err := s.client.Submit(tx)
+	if err != nil {
+		return nil, err
+	}
+	outcome.Submissions++
+	outcome.Result = result.EngineResult
+	glog.V(1).Infof("Submitted %s: %s", outcome.Hash, result.EngineResult)
+	return result, nil
+}
+
+// Checks whether the transaction is in a validated ledger
+func (s *Submitter) check(outcome *SubmitOutcome) (bool, error) {
+	tx, err := s.client.Tx(outcome.Hash)
+	if cmdErr, ok := err.(*CommandError); ok && cmdErr.Name == "txnNotFound" {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !tx.Validated {
+		return false, nil
+	}
+	outcome.Validated = true
+	outcome.Result = tx.MetaData.TransactionResult
+	outcome.Transaction = &tx.TransactionWithMetaData
+	return true, nil
+}
+
+// The transaction can only be considered expired if the server has every
+// ledger from submission up to LastLedgerSequence.
+func (s *Submitter) expired(outcome *SubmitOutcome, start, lastLedger uint32) error {
+	info, err := s.client.ServerInfo()
+	if err != nil {
+		return err
+	}
+	ledgers, err := info.Info.Ledgers()
+	if err != nil {
+		return err
+	}
+	for seq := start; seq <= lastLedger; seq++ {
+		if !ledgers.Contains(seq) {
+			return fmt.Errorf("submit: %s outcome unknown, server is missing ledger %d", outcome.Hash, seq)
+		}
+	}
+	outcome.Expired = true
+	return nil
+}
+
+func (s *Submitter) validatedLedger() (uint32, error) {
+	info, err := s.client.ServerInfo()
+	if err != nil {
+		return 0, err
+	}
+	if info.Info.ValidatedLedger == nil {
+		return 0, fmt.Errorf("submit: server has no validated ledger")
+	}
+	return info.Info.ValidatedLedger.LedgerSequence, nil
+}
+
+// Waits for the next ledger, using the ledger stream if the client has one,
+// and returns the latest validated ledger sequence.
+func (s *Submitter) wait() (uint32, error) {
+	if remote, ok := s.client.(*Remote); ok {
+		if _, err := remote.waitForNextLedger(); err != nil {
+			return 0, err
+		}
+	} else {
+		time.Sleep(s.PollInterval)
+	}
+	return s.validatedLedger()
+}
+
+// tef and tem results can never succeed, whereas tec results have claimed
+// a fee and will be validated
+func isFinalSubmitResult(result data.TransactionResult) bool {
+	name := result.String()
+	return strings.HasPrefix(name, "tef") || strings.HasPrefix(name, "tem")
+}
+
+func isRetrySubmitResult(result data.TransactionResult) bool {
+	name := result.String()
+	return (strings.HasPrefix(name, "ter") && !result.Queued()) || strings.HasPrefix(name, "tel")
+}
@@ -0,0 +1,256 @@
+package websockets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+	. "gopkg.in/check.v1"
+)
+
+type SubmitterSuite struct{}
+
+var _ = Suite(&SubmitterSuite{})
+
+// A scripted rippled which advances one validated ledger per server_info
+type fakeRippled struct {
+	sync.Mutex
+	validated   uint32
+	sequence    uint32
+	tickets     []uint32
+	results     []string
+	submissions []data.Transaction
+	applied     bool
+}
+
+func (f *fakeRippled) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	var request struct {
+		Method string
+		Params []map[string]interface{}
+	}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := f.handle(request.Method, request.Params[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, ok := result["error"]; ok {
+		result["status"] = "error"
+	} else {
+		result["status"] = "success"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+}
+
+func (f *fakeRippled) handle(method string, params map[string]interface{}) (map[string]interface{}, error) {
+	switch method {
+	case "server_info":
+		f.validated++
+		return map[string]interface{}{"info": map[string]interface{}{
+			"complete_ledgers": fmt.Sprintf("1-%d", f.validated),
+			"validated_ledger": map[string]interface{}{"seq": f.validated},
+		}}, nil
+	case "account_info":
+		return map[string]interface{}{"account_data": map[string]interface{}{
+			"LedgerEntryType": "AccountRoot",
+			"Account":         params["account"],
+			"Sequence":        f.sequence,
+		}}, nil
+	case "account_objects":
+		var objects []interface{}
+		for _, ticket := range f.tickets {
+			objects = append(objects, map[string]interface{}{
+				"LedgerEntryType": "Ticket",
+				"Account":         params["account"],
+				"TicketSequence":  ticket,
+				"index":           fmt.Sprintf("%064X", ticket),
+			})
+		}
+		return map[string]interface{}{"account": params["account"], "account_objects": objects}, nil
+	case "fee":
+		return map[string]interface{}{"drops": map[string]interface{}{
+			"base_fee":        "10",
+			"open_ledger_fee": "12",
+		}}, nil
+	case "ledger_current":
+		return map[string]interface{}{"ledger_current_index": f.validated + 1}, nil
+	case "submit":
+		b, err := hex.DecodeString(params["tx_blob"].(string))
+		if err != nil {
+			return nil, err
+		}
+		tx, err := data.ReadTransaction(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		if *tx.GetHash(), _, err = data.Raw(tx); err != nil {
+			return nil, err
+		}
+		f.submissions = append(f.submissions, tx)
+		result := f.results[0]
+		if len(f.results) > 1 {
+			f.results = f.results[1:]
+		}
+		f.applied = result == "tesSUCCESS"
+		return map[string]interface{}{"engine_result": result, "tx_blob": params["tx_blob"]}, nil
+	case "tx":
+		if !f.applied {
+			return map[string]interface{}{"error": "txnNotFound", "error_code": 29}, nil
+		}
+		b, err := json.Marshal(f.submissions[len(f.submissions)-1])
+		if err != nil {
+			return nil, err
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(b, &result); err != nil {
+			return nil, err
+		}
+		result["validated"] = true
+		result["ledger_index"] = f.validated
+		result["meta"] = map[string]interface{}{
+			"TransactionIndex":  0,
+			"TransactionResult": "tesSUCCESS",
+			"AffectedNodes":     []interface{}{},
+		}
+		return result, nil
+	default:
+		return map[string]interface{}{"error": "unknownCmd"}, nil
+	}
+}
+
+func newTestSubmitter(c *C, f *fakeRippled) (*Submitter, func()) {
+	server := httptest.NewServer(f)
+	seed, err := data.NewSeedFromAddress("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	c.Assert(err, IsNil)
+	key, err := crypto.NewECDSAKey(seed[:])
+	c.Assert(err, IsNil)
+	var sequence uint32
+	s := NewSubmitter(NewRPCClient(server.URL, nil), key, &sequence)
+	s.PollInterval = time.Millisecond
+	s.LedgerOffset = 4
+	return s, server.Close
+}
+
+func newTestPayment(c *C) *data.Payment {
+	destination, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	amount, err := data.NewAmount("1000000")
+	c.Assert(err, IsNil)
+	return &data.Payment{
+		TxBase: data.TxBase{
+			TransactionType: data.PAYMENT,
+		},
+		Destination: *destination,
+		Amount:      *amount,
+	}
+}
+
+func (s *SubmitterSuite) TestResubmitUntilValidated(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, results: []string{"terPRE_SEQ", "tesSUCCESS"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+
+	tx := newTestPayment(c)
+	outcome, err := submitter.Submit(tx)
+	c.Assert(err, IsNil)
+	c.Assert(outcome.Validated, Equals, true)
+	c.Assert(outcome.Expired, Equals, false)
+	c.Assert(outcome.Submissions, Equals, 2)
+	c.Assert(outcome.Result.String(), Equals, "tesSUCCESS")
+	c.Assert(outcome.Transaction.GetHash().String(), Equals, outcome.Hash.String())
+
+	// Autofilled fields
+	c.Assert(tx.Sequence, Equals, uint32(7))
+	c.Assert(tx.Fee.String(), Equals, "0.000012")
+	c.Assert(*tx.LastLedgerSequence, Equals, uint32(105))
+	c.Assert(tx.Account.IsZero(), Equals, false)
+	ok, err := data.CheckSignature(f.submissions[0])
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *SubmitterSuite) TestExpired(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, results: []string{"terQUEUED"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+
+	outcome, err := submitter.Submit(newTestPayment(c))
+	c.Assert(err, ErrorMatches, ".*expired at ledger 105.*")
+	c.Assert(outcome.Validated, Equals, false)
+	c.Assert(outcome.Expired, Equals, true)
+	// Queued transactions are not resubmitted
+	c.Assert(outcome.Submissions, Equals, 1)
+}
+
+func (s *SubmitterSuite) TestMalformed(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, results: []string{"temBAD_FEE"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+
+	outcome, err := submitter.Submit(newTestPayment(c))
+	c.Assert(err, ErrorMatches, ".*temBAD_FEE")
+	c.Assert(outcome.Validated, Equals, false)
+	c.Assert(outcome.Submissions, Equals, 1)
+}
+
+func newTestAllocatorFor(c *C, submitter *Submitter) *SequenceAllocator {
+	id, err := crypto.SignerId(submitter.signer)
+	c.Assert(err, IsNil)
+	var account data.Account
+	copy(account[:], id)
+	submitter.Sequences = NewSequenceAllocator(submitter.client, account)
+	return submitter.Sequences
+}
+
+func (s *SubmitterSuite) TestReallocate(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, results: []string{"tefPAST_SEQ", "tesSUCCESS"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+	newTestAllocatorFor(c, submitter)
+
+	outcome, err := submitter.Submit(newTestPayment(c))
+	c.Assert(err, IsNil)
+	c.Assert(outcome.Validated, Equals, true)
+	c.Assert(f.submissions, HasLen, 2)
+	c.Assert(f.submissions[0].GetBase().Sequence, Equals, uint32(7))
+	c.Assert(f.submissions[1].GetBase().Sequence, Equals, uint32(8))
+}
+
+func (s *SubmitterSuite) TestSubmitTickets(c *C) {
+	f := &fakeRippled{validated: 100, sequence: 7, tickets: []uint32{3, 4}, results: []string{"tesSUCCESS"}}
+	submitter, done := newTestSubmitter(c, f)
+	defer done()
+	allocator := newTestAllocatorFor(c, submitter)
+	submitter.UseTickets = true
+	c.Assert(allocator.LoadTickets(), IsNil)
+
+	outcome, err := submitter.Submit(newTestPayment(c))
+	c.Assert(err, IsNil)
+	c.Assert(outcome.Validated, Equals, true)
+	payment := f.submissions[0].(*data.Payment)
+	c.Assert(payment.Sequence, Equals, uint32(0))
+	c.Assert(*payment.TicketSequence, Equals, uint32(3))
+	c.Assert(allocator.Tickets(), Equals, 1)
+
+	// A ticket which was not used goes back into the pool
+	f.results = []string{"temBAD_FEE"}
+	outcome, err = submitter.Submit(newTestPayment(c))
+	c.Assert(err, ErrorMatches, ".*temBAD_FEE")
+	c.Assert(outcome.Rejected, Equals, true)
+	c.Assert(allocator.Tickets(), Equals, 1)
+	ticket, ok := allocator.Ticket()
+	c.Assert(ok, Equals, true)
+	c.Assert(ticket, Equals, uint32(4))
+}
@@ -1,6 +1,8 @@
 package websockets
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 
 	"github.com/rubblelabs/ripple/data"
@@ -19,6 +21,11 @@ type LedgerStreamMsg struct {
 	TxnCount         uint32          `json:"txn_count"` // Only streamed, not in the subscribe result.
 }
 
+// Ledger ranges validated by the server
+func (l *LedgerStreamMsg) Ledgers() (data.LedgerRangeSlice, error) {
+	return data.NewLedgerRangeSlice(l.ValidatedLedgers)
+}
+
 // Fields from subscribed transaction stream messages
 type TransactionStreamMsg struct {
 	Transaction         data.TransactionWithMetaData `json:"transaction"`
@@ -48,19 +55,140 @@ func (s *ServerStreamMsg) TransactionCost() uint64 {
 	return (s.BaseFee * s.LoadFactor) / s.LoadBase
 }
 
+// Fields from subscribed validations stream messages
+type ValidationStreamMsg struct {
+	Amendments          []data.Hash256  `json:"amendments,omitempty"`
+	BaseFee             *uint64         `json:"base_fee,omitempty"`
+	CloseTime           *uint32         `json:"close_time,omitempty"`
+	Cookie              string          `json:"cookie,omitempty"`
+	Data                string          `json:"data"`
+	Flags               uint32          `json:"flags"`
+	Full                bool            `json:"full"`
+	LedgerHash          data.Hash256    `json:"ledger_hash"`
+	LedgerSequence      uint32          `json:"ledger_index,string"`
+	LoadFee             *uint32         `json:"load_fee,omitempty"`
+	MasterKey           string          `json:"master_key,omitempty"`
+	NetworkID           *uint32         `json:"network_id,omitempty"`
+	ReserveBase         *uint32         `json:"reserve_base,omitempty"`
+	ReserveIncrement    *uint32         `json:"reserve_inc,omitempty"`
+	ServerVersion       string          `json:"server_version,omitempty"`
+	Signature           string          `json:"signature"`
+	SigningTime         data.RippleTime `json:"signing_time"`
+	ValidatedHash       *data.Hash256   `json:"validated_hash,omitempty"`
+	ValidationPublicKey string          `json:"validation_public_key"`
+}
+
+// Decodes the signed validation carried in the data field
+func (v *ValidationStreamMsg) Validation() (*data.Validation, error) {
+	b, err := hex.DecodeString(v.Data)
+	if err != nil {
+		return nil, err
+	}
+	return data.ReadValidation(bytes.NewReader(b))
+}
+
+// Fields from subscribed manifests stream messages
+type ManifestStreamMsg struct {
+	MasterKey       string `json:"master_key"`
+	MasterSignature string `json:"master_signature"`
+	Manifest        string `json:"manifest"`
+	Sequence        uint32 `json:"seq"`
+	Signature       string `json:"signature"`
+	SigningKey      string `json:"signing_key"`
+}
+
+// Fields from subscribed consensus stream messages
+type ConsensusStreamMsg struct {
+	Phase string `json:"consensus"`
+}
+
+// Fields from subscribed peer_status stream messages. Requires admin access.
+type PeerStatusStreamMsg struct {
+	Action         string          `json:"action"`
+	Date           data.RippleTime `json:"date"`
+	LedgerHash     *data.Hash256   `json:"ledger_hash,omitempty"`
+	LedgerSequence uint32          `json:"ledger_index,omitempty"`
+	LedgerMax      uint32          `json:"ledger_index_max,omitempty"`
+	LedgerMin      uint32          `json:"ledger_index_min,omitempty"`
+	Status         string          `json:"status,omitempty"`
+}
+
+// A summary of the trading in one order book during a ledger.
+// Currencies are either "XRP_drops" or "issuer/currency".
+type BookChange struct {
+	CurrencyA string     `json:"currency_a"`
+	CurrencyB string     `json:"currency_b"`
+	VolumeA   data.Value `json:"volume_a"`
+	VolumeB   data.Value `json:"volume_b"`
+	High      data.Value `json:"high"`
+	Low       data.Value `json:"low"`
+	Open      data.Value `json:"open"`
+	Close     data.Value `json:"close"`
+}
+
+// Fields from subscribed book_changes stream messages
+type BookChangesStreamMsg struct {
+	Changes        []BookChange    `json:"changes"`
+	LedgerHash     data.Hash256    `json:"ledger_hash"`
+	LedgerSequence uint32          `json:"ledger_index"`
+	LedgerTime     data.RippleTime `json:"ledger_time"`
+	Validated      bool            `json:"validated"`
+}
+
 // Map message types to the appropriate data structure
 var streamMessageFactory = map[string]func() interface{}{
-	"ledgerClosed": func() interface{} { return &LedgerStreamMsg{} },
-	"transaction":  func() interface{} { return &TransactionStreamMsg{} },
-	"serverStatus": func() interface{} { return &ServerStreamMsg{} },
-	"path_find":    func() interface{} { return &PathFindCreateResult{} },
+	"ledgerClosed":       func() interface{} { return &LedgerStreamMsg{} },
+	"transaction":        func() interface{} { return &TransactionStreamMsg{} },
+	"serverStatus":       func() interface{} { return &ServerStreamMsg{} },
+	"path_find":          func() interface{} { return &PathFindCreateResult{} },
+	"validationReceived": func() interface{} { return &ValidationStreamMsg{} },
+	"manifestReceived":   func() interface{} { return &ManifestStreamMsg{} },
+	"consensusPhase":     func() interface{} { return &ConsensusStreamMsg{} },
+	"peerStatusChange":   func() interface{} { return &PeerStatusStreamMsg{} },
+	"bookChanges":        func() interface{} { return &BookChangesStreamMsg{} },
+}
+
+// Stream names accepted by subscribe and unsubscribe
+const (
+	StreamLedger               = "ledger"
+	StreamTransactions         = "transactions"
+	StreamTransactionsProposed = "transactions_proposed"
+	StreamServer               = "server"
+	StreamValidations          = "validations"
+	StreamManifests            = "manifests"
+	StreamConsensus            = "consensus"
+	StreamPeerStatus           = "peer_status"
+	StreamBookChanges          = "book_changes"
+)
+
+// The streams, accounts and books to subscribe to or unsubscribe from.
+// Transactions for accounts and books arrive as TransactionStreamMsg.
+type SubscribeRequest struct {
+	Streams          []string                `json:"streams,omitempty"`
+	Accounts         []data.Account          `json:"accounts,omitempty"`
+	AccountsProposed []data.Account          `json:"accounts_proposed,omitempty"`
+	Books            []OrderBookSubscription `json:"books,omitempty"`
+}
+
+func (s *SubscribeRequest) hasStream(stream string) bool {
+	for _, name := range s.Streams {
+		if name == stream {
+			return true
+		}
+	}
+	return false
 }
 
 type SubscribeCommand struct {
 	*Command
-	Streams []string                `json:"streams"`
-	Books   []OrderBookSubscription `json:"books,omitempty"`
-	Result  *SubscribeResult        `json:"result,omitempty"`
+	SubscribeRequest
+	Result *SubscribeResult `json:"result,omitempty"`
+}
+
+type UnsubscribeCommand struct {
+	*Command
+	SubscribeRequest
+	Result *struct{} `json:"result,omitempty"`
 }
 
 type SubscribeResult struct {
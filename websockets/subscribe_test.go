@@ -144,6 +144,88 @@ func (s *MessagesSuite) TestProposedTransactionStreamMsg(c *C) {
 	c.Assert(offer.TakerPays.String(), Equals, "4285.465077979/CNY/razqQKzJRdB4UxFPWf5NEpEG3WMkmwgcXA")
 }
 
+func (s *MessagesSuite) TestValidationStreamMsg(c *C) {
+	msg := streamMessageFactory["validationReceived"]().(*ValidationStreamMsg)
+	readResponseFile(c, msg, "testdata/validation_stream.json")
+
+	c.Assert(msg.LedgerSequence, Equals, uint32(6951500))
+	c.Assert(msg.LedgerHash.String(), Equals, "1A8194A501C8C9AC779A96495365D596371C09636E63F62BB0B4B81CF1239BAF")
+	c.Assert(msg.Full, Equals, true)
+	c.Assert(msg.ValidationPublicKey, Equals, "n9L81uNCaPgtUJfaHh89gmdvXKAmSt5Gdsw2g1iPWaPkAHW5Nm4C")
+
+	v, err := msg.Validation()
+	c.Assert(err, IsNil)
+	c.Assert(v.LedgerSequence, Equals, msg.LedgerSequence)
+	c.Assert(v.LedgerHash, Equals, msg.LedgerHash)
+	c.Assert(v.SigningTime, Equals, msg.SigningTime)
+	ok, err := data.CheckSignature(v)
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *MessagesSuite) TestManifestStreamMsg(c *C) {
+	msg := streamMessageFactory["manifestReceived"]().(*ManifestStreamMsg)
+	readResponseFile(c, msg, "testdata/manifest_stream.json")
+
+	c.Assert(msg.MasterKey, Equals, "nHUFE9prPXPrHcG3SkwP1UzAQbSphqyQkQK9ATXLZsfkezhhda3p")
+	c.Assert(msg.SigningKey, Equals, "n9LiE1gpUGws1kFGKCM9rVFNYPVS4QziwkQn281EFXX7TViCp2RC")
+	c.Assert(msg.Sequence, Equals, uint32(2))
+}
+
+func (s *MessagesSuite) TestConsensusStreamMsg(c *C) {
+	msg := streamMessageFactory["consensusPhase"]().(*ConsensusStreamMsg)
+	readResponseFile(c, msg, "testdata/consensus_stream.json")
+
+	c.Assert(msg.Phase, Equals, "accepted")
+}
+
+func (s *MessagesSuite) TestPeerStatusStreamMsg(c *C) {
+	msg := streamMessageFactory["peerStatusChange"]().(*PeerStatusStreamMsg)
+	readResponseFile(c, msg, "testdata/peer_status_stream.json")
+
+	c.Assert(msg.Action, Equals, "CLOSING_LEDGER")
+	c.Assert(msg.LedgerSequence, Equals, uint32(18853106))
+	c.Assert(msg.LedgerMin, Equals, uint32(18852082))
+	c.Assert(msg.LedgerHash.String(), Equals, "4D4CD9CD543F0C1EF023CC457F5BEFEA59EEF73E4552542D40E7C4FA08D3C320")
+}
+
+func (s *MessagesSuite) TestBookChangesStreamMsg(c *C) {
+	msg := streamMessageFactory["bookChanges"]().(*BookChangesStreamMsg)
+	readResponseFile(c, msg, "testdata/book_changes_stream.json")
+
+	c.Assert(msg.LedgerSequence, Equals, uint32(88530953))
+	c.Assert(msg.Validated, Equals, true)
+	c.Assert(msg.Changes, HasLen, 1)
+	change := msg.Changes[0]
+	c.Assert(change.CurrencyA, Equals, "XRP_drops")
+	c.Assert(change.CurrencyB, Equals, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B/USD")
+	c.Assert(change.VolumeB.String(), Equals, "11.2")
+	c.Assert(change.Close.String(), Equals, "2055447.6")
+}
+
+func (s *MessagesSuite) TestSubscribeRequest(c *C) {
+	account, err := data.NewAccountFromAddress("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B")
+	c.Assert(err, IsNil)
+	cmd := &UnsubscribeCommand{
+		Command: newCommand("unsubscribe"),
+		SubscribeRequest: SubscribeRequest{
+			Streams:  []string{StreamValidations, StreamBookChanges},
+			Accounts: []data.Account{*account},
+		},
+	}
+	b, err := json.Marshal(cmd)
+	c.Assert(err, IsNil)
+	var fields map[string]interface{}
+	c.Assert(json.Unmarshal(b, &fields), IsNil)
+	c.Assert(fields["command"], Equals, "unsubscribe")
+	c.Assert(fields["streams"], DeepEquals, []interface{}{"validations", "book_changes"})
+	c.Assert(fields["accounts"], DeepEquals, []interface{}{"rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B"})
+	c.Assert(fields["books"], IsNil)
+	c.Assert(fields["accounts_proposed"], IsNil)
+	c.Assert(cmd.hasStream(StreamBookChanges), Equals, true)
+	c.Assert(cmd.hasStream(StreamLedger), Equals, false)
+}
+
 func BenchmarkProposedTransactionStreamJSON(b *testing.B) {
 	bites, err := ioutil.ReadFile("testdata/proposed_transaction_stream.json")
 	if err != nil {